@@ -0,0 +1,182 @@
+package audit
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/dhth/ecsv/internal/discover"
+	"github.com/dhth/ecsv/internal/types"
+)
+
+// Cluster identifies an ECS cluster in a specific AWS account/region (as
+// determined by the AWS config used to access it).
+type Cluster struct {
+	AWSConfigKey string
+	Name         string
+}
+
+// ClusterServices holds the services that exist in a cluster, or the error
+// encountered while listing them.
+type ClusterServices struct {
+	Services []string
+	Err      error
+}
+
+// UncoveredService is a service that exists in a configured cluster, but
+// isn't covered by any system.
+type UncoveredService struct {
+	Envs         []string
+	Cluster      string
+	Service      string
+	SuggestedKey string
+}
+
+// MissingService is a config entry whose service doesn't exist in its cluster.
+type MissingService struct {
+	SystemKey string
+	Env       string
+	Cluster   string
+	Service   string
+}
+
+// ClusterError is an error encountered while listing a cluster's services.
+type ClusterError struct {
+	Envs    []string
+	Cluster string
+	Err     error
+}
+
+type Report struct {
+	Uncovered []UncoveredService
+	Missing   []MissingService
+	Errors    []ClusterError
+}
+
+func (r Report) HasFindings() bool {
+	return len(r.Uncovered) > 0 || len(r.Missing) > 0
+}
+
+// Clusters returns the clusters referred to by config entries, in the order
+// they first appear.
+func Clusters(versions []types.VersionsConfig) []Cluster {
+	var clusters []Cluster
+	for _, v := range versions {
+		c := clusterOf(v)
+		if !slices.Contains(clusters, c) {
+			clusters = append(clusters, c)
+		}
+	}
+
+	return clusters
+}
+
+// Compare checks the services that exist in the clusters of versions against
+// config entries. A service counts as covered if any entry in all (the
+// unfiltered config entries, versions included) refers to it, so that filtering
+// doesn't cause services of other systems to be reported as uncovered.
+// Services matching ignore (if provided) are never reported as uncovered.
+func Compare(versions, all []types.VersionsConfig, existing map[Cluster]ClusterServices, ignore *regexp.Regexp) Report {
+	var report Report
+
+	configured := make(map[Cluster]map[string]bool)
+	for _, v := range all {
+		c := clusterOf(v)
+		if configured[c] == nil {
+			configured[c] = make(map[string]bool)
+		}
+		configured[c][v.ServiceName] = true
+	}
+
+	envs := make(map[Cluster][]string)
+	for _, v := range versions {
+		c := clusterOf(v)
+		if !slices.Contains(envs[c], v.Env) {
+			envs[c] = append(envs[c], v.Env)
+		}
+	}
+
+	for _, c := range Clusters(versions) {
+		cs := existing[c]
+		if cs.Err != nil {
+			report.Errors = append(report.Errors, ClusterError{Envs: envs[c], Cluster: c.Name, Err: cs.Err})
+			continue
+		}
+
+		for _, service := range cs.Services {
+			if configured[c][service] || ignore != nil && ignore.MatchString(service) {
+				continue
+			}
+
+			report.Uncovered = append(report.Uncovered, UncoveredService{
+				Envs:         envs[c],
+				Cluster:      c.Name,
+				Service:      service,
+				SuggestedKey: discover.GuessKey(service, envs[c][0]),
+			})
+		}
+	}
+
+	for _, v := range versions {
+		cs := existing[clusterOf(v)]
+		if cs.Err != nil || slices.Contains(cs.Services, v.ServiceName) {
+			continue
+		}
+
+		report.Missing = append(report.Missing, MissingService{
+			SystemKey: v.Key,
+			Env:       v.Env,
+			Cluster:   v.ClusterName,
+			Service:   v.ServiceName,
+		})
+	}
+
+	sort.SliceStable(report.Uncovered, func(i, j int) bool {
+		if report.Uncovered[i].Cluster != report.Uncovered[j].Cluster {
+			return report.Uncovered[i].Cluster < report.Uncovered[j].Cluster
+		}
+		return report.Uncovered[i].Service < report.Uncovered[j].Service
+	})
+
+	return report
+}
+
+func (r Report) String() string {
+	var b strings.Builder
+
+	if len(r.Uncovered) > 0 {
+		fmt.Fprintf(&b, "Services not covered by any system (%d)\n\n", len(r.Uncovered))
+		for _, u := range r.Uncovered {
+			fmt.Fprintf(&b, "  - %s/%s (%s); suggested key: %s\n", u.Cluster, u.Service, strings.Join(u.Envs, ", "), u.SuggestedKey)
+		}
+		b.WriteString("\n")
+	}
+
+	if len(r.Missing) > 0 {
+		fmt.Fprintf(&b, "Configured services that don't exist (%d)\n\n", len(r.Missing))
+		for _, m := range r.Missing {
+			fmt.Fprintf(&b, "  - %s (%s): %s/%s\n", m.SystemKey, m.Env, m.Cluster, m.Service)
+		}
+		b.WriteString("\n")
+	}
+
+	if len(r.Errors) > 0 {
+		fmt.Fprintf(&b, "Clusters that couldn't be checked (%d)\n\n", len(r.Errors))
+		for _, e := range r.Errors {
+			fmt.Fprintf(&b, "  - %s (%s): %s\n", e.Cluster, strings.Join(e.Envs, ", "), e.Err.Error())
+		}
+		b.WriteString("\n")
+	}
+
+	if !r.HasFindings() && len(r.Errors) == 0 {
+		b.WriteString("config covers all services in the configured clusters\n")
+	}
+
+	return b.String()
+}
+
+func clusterOf(v types.VersionsConfig) Cluster {
+	return Cluster{AWSConfigKey: v.AWSConfigKey(), Name: v.ClusterName}
+}
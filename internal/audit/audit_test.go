@@ -0,0 +1,56 @@
+package audit
+
+import (
+	"errors"
+	"regexp"
+	"slices"
+	"testing"
+
+	"github.com/dhth/ecsv/internal/types"
+)
+
+var errAccessDenied = errors.New("access denied")
+
+func TestCompare(t *testing.T) {
+	entry := func(key, env, cluster, service string) types.VersionsConfig {
+		return types.VersionsConfig{
+			Key:                 key,
+			Env:                 env,
+			AWSConfigSourceType: types.SharedCfgProfileType,
+			AWSConfigSource:     env,
+			AWSRegion:           "eu-central-1",
+			ClusterName:         cluster,
+			ServiceName:         service,
+		}
+	}
+
+	versions := []types.VersionsConfig{
+		entry("service-a", "qa", "main-qa", "service-a-qa"),
+		entry("service-b", "qa", "main-qa", "service-b-qa"),
+		entry("service-a", "prod", "main-prod", "service-a-prod"),
+	}
+	// entries filtered out of versions still count towards coverage
+	all := append(slices.Clone(versions), entry("service-d", "qa", "main-qa", "service-d-qa"))
+	qa := Cluster{AWSConfigKey: "qa:eu-central-1", Name: "main-qa"}
+	prod := Cluster{AWSConfigKey: "prod:eu-central-1", Name: "main-prod"}
+
+	existing := map[Cluster]ClusterServices{
+		qa:   {Services: []string{"service-c-qa", "service-a-qa", "service-d-qa", "migrations-qa"}},
+		prod: {Err: errAccessDenied},
+	}
+
+	got := Compare(versions, all, existing, regexp.MustCompile("^migrations"))
+
+	if len(got.Uncovered) != 1 || got.Uncovered[0].Service != "service-c-qa" || got.Uncovered[0].SuggestedKey != "service-c" {
+		t.Errorf("got unexpected uncovered services: %+v", got.Uncovered)
+	}
+
+	expectedMissing := MissingService{SystemKey: "service-b", Env: "qa", Cluster: "main-qa", Service: "service-b-qa"}
+	if len(got.Missing) != 1 || got.Missing[0] != expectedMissing {
+		t.Errorf("got unexpected missing services: %+v", got.Missing)
+	}
+
+	if len(got.Errors) != 1 || got.Errors[0].Cluster != "main-prod" {
+		t.Errorf("got unexpected errors: %+v", got.Errors)
+	}
+}
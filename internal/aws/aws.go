@@ -2,6 +2,9 @@ package aws
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -9,10 +12,17 @@ import (
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
 	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	ecstypes "github.com/aws/aws-sdk-go-v2/service/ecs/types"
 	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/dhth/ecsv/internal/logging"
 	"github.com/dhth/ecsv/internal/types"
 )
 
+var (
+	errContainerEnvMissing   = errors.New("container doesn't have environment variable")
+	errContainerLabelMissing = errors.New("container doesn't have docker label")
+)
+
 type Config struct {
 	Config aws.Config
 	Err    error
@@ -32,60 +42,77 @@ func GetConfig(system types.VersionsConfig) (aws.Config, error) {
 		if err != nil {
 			return cfg, err
 		}
+		if logging.Enabled() {
+			cfg.APIOptions = append(cfg.APIOptions, logCalls)
+		}
 		stsSvc := sts.NewFromConfig(cfg)
 		creds := stscreds.NewAssumeRoleProvider(stsSvc, system.AWSConfigSource)
 
 		cfg.Credentials = aws.NewCredentialsCache(creds)
+		return cfg, nil
 	default:
 		cfg, err = config.LoadDefaultConfig(context.TODO(),
 			config.WithRegion(system.AWSRegion))
 	}
+
+	if err == nil && logging.Enabled() {
+		cfg.APIOptions = append(cfg.APIOptions, logCalls)
+	}
+
 	return cfg, err
 }
 
-func FetchSystemVersion(system types.VersionsConfig, awsConfig Config) types.VersionResult {
+func FetchSystemVersion(ctx context.Context, system types.VersionsConfig, awsConfig Config) types.VersionResult {
 	ecsClient := ecs.NewFromConfig(awsConfig.Config)
+	ctx = logging.WithSystem(ctx, system.Key, system.Env)
 
 	services := make([]string, 1)
 	services[0] = system.ServiceName
-	svcs, err := ecsClient.DescribeServices(context.Background(), &ecs.DescribeServicesInput{Services: services, Cluster: &system.ClusterName})
+	svcs, err := ecsClient.DescribeServices(ctx, &ecs.DescribeServicesInput{Services: services, Cluster: &system.ClusterName})
 	if err != nil {
 		return types.VersionResult{
 			SystemKey: system.Key,
 			Env:       system.Env,
-			Err:       err,
+			Err:       wrapFetchError(system, err),
 		}
 	}
 	for _, svc := range svcs.Services {
 		td := svc.TaskDefinition
 
-		describeTDOutput, err := ecsClient.DescribeTaskDefinition(context.Background(), &ecs.DescribeTaskDefinitionInput{TaskDefinition: td})
+		describeTDOutput, err := ecsClient.DescribeTaskDefinition(ctx, &ecs.DescribeTaskDefinitionInput{TaskDefinition: td})
 		if err != nil {
 			return types.VersionResult{
 				SystemKey: system.Key,
 				Env:       system.Env,
-				Err:       err,
+				Err:       wrapFetchError(system, err),
 			}
 		}
 		containerDefs := describeTDOutput.TaskDefinition.ContainerDefinitions
 		for _, containerDef := range containerDefs {
 			if *containerDef.Name == system.ContainerName {
-				versionEls := strings.Split(*containerDef.Image, ":")
-				var version string
-				if len(versionEls) > 0 {
-					version = versionEls[len(versionEls)-1]
+				version, err := containerVersion(containerDef, system.VersionFrom)
+				if err != nil {
+					return types.VersionResult{
+						SystemKey: system.Key,
+						Env:       system.Env,
+						Err:       err,
+					}
 				}
+
 				var registeredAt *time.Time
+				var taskDefinition *types.TaskDefinition
 				if describeTDOutput != nil && describeTDOutput.TaskDefinition != nil {
 					registeredAt = describeTDOutput.TaskDefinition.RegisteredAt
+					taskDefinition = taskDefinitionDetails(describeTDOutput.TaskDefinition, containerDef)
 				}
 
 				return types.VersionResult{
-					Found:        true,
-					SystemKey:    system.Key,
-					Env:          system.Env,
-					Version:      version,
-					RegisteredAt: registeredAt,
+					Found:          true,
+					SystemKey:      system.Key,
+					Env:            system.Env,
+					Version:        version,
+					RegisteredAt:   registeredAt,
+					TaskDefinition: taskDefinition,
 				}
 			}
 		}
@@ -96,3 +123,79 @@ func FetchSystemVersion(system types.VersionsConfig, awsConfig Config) types.Ver
 		Found:     false,
 	}
 }
+
+// containerVersion returns the version of a container, from its image tag, or
+// from an environment variable or docker label set in its definition.
+func containerVersion(containerDef ecstypes.ContainerDefinition, versionFrom types.VersionFrom) (string, error) {
+	switch versionFrom.Kind {
+	case types.EnvVarVersion:
+		// an empty value doesn't make for a version, so it's treated the same
+		// way as a missing one
+		for _, kv := range containerDef.Environment {
+			if aws.ToString(kv.Name) == versionFrom.Name && aws.ToString(kv.Value) != "" {
+				return aws.ToString(kv.Value), nil
+			}
+		}
+		return "", fmt.Errorf("%w: %s", errContainerEnvMissing, versionFrom.Name)
+	case types.DockerLabelVersion:
+		version := containerDef.DockerLabels[versionFrom.Name]
+		if version == "" {
+			return "", fmt.Errorf("%w: %s", errContainerLabelMissing, versionFrom.Name)
+		}
+		return version, nil
+	default:
+		return imageTag(aws.ToString(containerDef.Image)), nil
+	}
+}
+
+// taskDefinitionDetails returns the details of a task definition that are
+// relevant when comparing deployments across envs.
+func taskDefinitionDetails(td *ecstypes.TaskDefinition, containerDef ecstypes.ContainerDefinition) *types.TaskDefinition {
+	details := &types.TaskDefinition{
+		ARN:      aws.ToString(td.TaskDefinitionArn),
+		Family:   aws.ToString(td.Family),
+		Revision: td.Revision,
+		Image:    aws.ToString(containerDef.Image),
+		CPU:      aws.ToString(td.Cpu),
+		Memory:   aws.ToString(td.Memory),
+	}
+
+	if details.CPU == "" && containerDef.Cpu > 0 {
+		details.CPU = strconv.Itoa(int(containerDef.Cpu))
+	}
+
+	if details.Memory == "" && containerDef.Memory != nil {
+		details.Memory = strconv.Itoa(int(*containerDef.Memory))
+	}
+
+	return details
+}
+
+// imageTag returns the tag of an image URI, eg. "1.2.3" for
+// "123456789012.dkr.ecr.eu-central-1.amazonaws.com/service-a:1.2.3".
+func imageTag(image string) string {
+	versionEls := strings.Split(image, ":")
+	return versionEls[len(versionEls)-1]
+}
+
+// GetCallerIdentity returns the ARN of the identity that cfg's credentials
+// belong to.
+func GetCallerIdentity(ctx context.Context, cfg aws.Config) (string, error) {
+	out, err := sts.NewFromConfig(cfg).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return "", err
+	}
+
+	return aws.ToString(out.Arn), nil
+}
+
+// CheckDescribeServices verifies that cfg's credentials are allowed to
+// describe a service.
+func CheckDescribeServices(ctx context.Context, cfg aws.Config, cluster, service string) error {
+	_, err := ecs.NewFromConfig(cfg).DescribeServices(ctx, &ecs.DescribeServicesInput{
+		Cluster:  &cluster,
+		Services: []string{service},
+	})
+
+	return err
+}
@@ -0,0 +1,147 @@
+package aws
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ecstypes "github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/dhth/ecsv/internal/types"
+)
+
+func TestContainerVersion(t *testing.T) {
+	containerDef := ecstypes.ContainerDefinition{
+		Name:  aws.String("payments-api"),
+		Image: aws.String("123456789012.dkr.ecr.eu-central-1.amazonaws.com/payments-api:main"),
+		Environment: []ecstypes.KeyValuePair{
+			{Name: aws.String("LOG_LEVEL"), Value: aws.String("info")},
+			{Name: aws.String("APP_VERSION"), Value: aws.String("1.4.2")},
+			{Name: aws.String("BUILD_VERSION"), Value: aws.String("")},
+		},
+		DockerLabels: map[string]string{
+			"org.opencontainers.image.version": "1.4.1",
+			"build.version":                    "",
+		},
+	}
+
+	testCases := []struct {
+		name        string
+		versionFrom types.VersionFrom
+		expected    string
+		err         error
+	}{
+		{
+			name:     "image tag",
+			expected: "main",
+		},
+		{
+			name:        "environment variable",
+			versionFrom: types.VersionFrom{Kind: types.EnvVarVersion, Name: "APP_VERSION"},
+			expected:    "1.4.2",
+		},
+		{
+			name:        "docker label",
+			versionFrom: types.VersionFrom{Kind: types.DockerLabelVersion, Name: "org.opencontainers.image.version"},
+			expected:    "1.4.1",
+		},
+		{
+			name:        "missing environment variable",
+			versionFrom: types.VersionFrom{Kind: types.EnvVarVersion, Name: "VERSION"},
+			err:         errContainerEnvMissing,
+		},
+		{
+			name:        "missing docker label",
+			versionFrom: types.VersionFrom{Kind: types.DockerLabelVersion, Name: "version"},
+			err:         errContainerLabelMissing,
+		},
+		{
+			name:        "empty environment variable",
+			versionFrom: types.VersionFrom{Kind: types.EnvVarVersion, Name: "BUILD_VERSION"},
+			err:         errContainerEnvMissing,
+		},
+		{
+			name:        "empty docker label",
+			versionFrom: types.VersionFrom{Kind: types.DockerLabelVersion, Name: "build.version"},
+			err:         errContainerLabelMissing,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := containerVersion(containerDef, tt.versionFrom)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("expected error %q, got %v", tt.err, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != tt.expected {
+				t.Errorf("expected version %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestTaskDefinitionDetails(t *testing.T) {
+	containerDef := ecstypes.ContainerDefinition{
+		Name:   aws.String("payments-api"),
+		Image:  aws.String("123456789012.dkr.ecr.eu-central-1.amazonaws.com/payments-api:1.4.2"),
+		Cpu:    128,
+		Memory: aws.Int32(256),
+	}
+
+	testCases := []struct {
+		name     string
+		td       ecstypes.TaskDefinition
+		expected types.TaskDefinition
+	}{
+		{
+			name: "task level limits",
+			td: ecstypes.TaskDefinition{
+				TaskDefinitionArn: aws.String("arn:aws:ecs:eu-central-1:123456789012:task-definition/payments-api:42"),
+				Family:            aws.String("payments-api"),
+				Revision:          42,
+				Cpu:               aws.String("512"),
+				Memory:            aws.String("1024"),
+			},
+			expected: types.TaskDefinition{
+				ARN:      "arn:aws:ecs:eu-central-1:123456789012:task-definition/payments-api:42",
+				Family:   "payments-api",
+				Revision: 42,
+				Image:    "123456789012.dkr.ecr.eu-central-1.amazonaws.com/payments-api:1.4.2",
+				CPU:      "512",
+				Memory:   "1024",
+			},
+		},
+		{
+			name: "container level limits",
+			td: ecstypes.TaskDefinition{
+				TaskDefinitionArn: aws.String("arn:aws:ecs:eu-central-1:123456789012:task-definition/payments-api:7"),
+				Family:            aws.String("payments-api"),
+				Revision:          7,
+			},
+			expected: types.TaskDefinition{
+				ARN:      "arn:aws:ecs:eu-central-1:123456789012:task-definition/payments-api:7",
+				Family:   "payments-api",
+				Revision: 7,
+				Image:    "123456789012.dkr.ecr.eu-central-1.amazonaws.com/payments-api:1.4.2",
+				CPU:      "128",
+				Memory:   "256",
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got := taskDefinitionDetails(&tt.td, containerDef)
+			if *got != tt.expected {
+				t.Errorf("got: %+v, expected: %+v", *got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	cfntypes "github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	"github.com/aws/smithy-go"
+	"github.com/dhth/ecsv/internal/logging"
+	"github.com/dhth/ecsv/internal/types"
+)
+
+var errStackOutputMissing = errors.New("stack doesn't have output")
+
+// CFNSource fetches versions from an output of a CloudFormation stack, for
+// pipelines that record what they deployed as a stack output.
+type CFNSource struct {
+	configs *ConfigCache
+}
+
+func NewCFNSource(configs *ConfigCache) *CFNSource {
+	return &CFNSource{configs: configs}
+}
+
+func (s *CFNSource) FetchVersion(ctx context.Context, system types.VersionsConfig) types.VersionResult {
+	cfg, err := s.configs.get(system)
+	if err != nil {
+		return types.VersionResult{
+			SystemKey: system.Key,
+			Env:       system.Env,
+			Err:       err,
+		}
+	}
+
+	return FetchStackOutputVersion(ctx, system, cfg)
+}
+
+// FetchStackOutputVersion fetches the version of a system that's recorded as
+// an output of a CloudFormation stack.
+func FetchStackOutputVersion(ctx context.Context, system types.VersionsConfig, cfg aws.Config) types.VersionResult {
+	ctx = logging.WithSystem(ctx, system.Key, system.Env)
+
+	out, err := cloudformation.NewFromConfig(cfg).DescribeStacks(ctx, &cloudformation.DescribeStacksInput{
+		StackName: &system.StackName,
+	})
+	if err != nil {
+		if isStackNotFound(err) {
+			return types.VersionResult{
+				SystemKey: system.Key,
+				Env:       system.Env,
+				Found:     false,
+			}
+		}
+
+		return types.VersionResult{
+			SystemKey: system.Key,
+			Env:       system.Env,
+			Err:       wrapFetchError(system, err),
+		}
+	}
+
+	if len(out.Stacks) == 0 {
+		return types.VersionResult{
+			SystemKey: system.Key,
+			Env:       system.Env,
+			Found:     false,
+		}
+	}
+
+	version, updatedAt, err := stackOutputVersion(out.Stacks[0], system.StackOutput)
+	if err != nil {
+		return types.VersionResult{
+			SystemKey: system.Key,
+			Env:       system.Env,
+			Err:       err,
+		}
+	}
+
+	return types.VersionResult{
+		Found:        true,
+		SystemKey:    system.Key,
+		Env:          system.Env,
+		Version:      version,
+		RegisteredAt: updatedAt,
+	}
+}
+
+// stackOutputVersion returns the value of a stack's output, along with when
+// the stack was last updated.
+func stackOutputVersion(stack cfntypes.Stack, output string) (string, *time.Time, error) {
+	updatedAt := stack.LastUpdatedTime
+	if updatedAt == nil {
+		updatedAt = stack.CreationTime
+	}
+
+	for _, o := range stack.Outputs {
+		if aws.ToString(o.OutputKey) == output {
+			return strings.TrimSpace(aws.ToString(o.OutputValue)), updatedAt, nil
+		}
+	}
+
+	return "", nil, fmt.Errorf("%w: %s", errStackOutputMissing, output)
+}
+
+// isStackNotFound reports whether err is CloudFormation's response for a stack
+// that doesn't exist, which it signals via a generic validation error.
+func isStackNotFound(err error) bool {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+
+	return apiErr.ErrorCode() == "ValidationError" && strings.Contains(apiErr.ErrorMessage(), "does not exist")
+}
+
+// CheckDescribeStacks verifies that cfg's credentials are allowed to describe
+// a CloudFormation stack.
+func CheckDescribeStacks(ctx context.Context, cfg aws.Config, stack string) error {
+	_, err := cloudformation.NewFromConfig(cfg).DescribeStacks(ctx, &cloudformation.DescribeStacksInput{
+		StackName: &stack,
+	})
+
+	return err
+}
@@ -0,0 +1,108 @@
+package aws
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cfntypes "github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	"github.com/aws/smithy-go"
+)
+
+func TestStackOutputVersion(t *testing.T) {
+	createdAt := time.Date(2026, 9, 1, 10, 0, 0, 0, time.UTC)
+	updatedAt := time.Date(2026, 10, 1, 10, 0, 0, 0, time.UTC)
+	outputs := []cfntypes.Output{
+		{OutputKey: aws.String("ServiceUrl"), OutputValue: aws.String("https://payments.example.com")},
+		{OutputKey: aws.String("Version"), OutputValue: aws.String(" 1.4.2\n")},
+	}
+
+	testCases := []struct {
+		name              string
+		stack             cfntypes.Stack
+		output            string
+		expected          string
+		expectedUpdatedAt time.Time
+		err               error
+	}{
+		{
+			name:              "updated stack",
+			stack:             cfntypes.Stack{Outputs: outputs, CreationTime: &createdAt, LastUpdatedTime: &updatedAt},
+			output:            "Version",
+			expected:          "1.4.2",
+			expectedUpdatedAt: updatedAt,
+		},
+		{
+			name:              "stack that was never updated",
+			stack:             cfntypes.Stack{Outputs: outputs, CreationTime: &createdAt},
+			output:            "Version",
+			expected:          "1.4.2",
+			expectedUpdatedAt: createdAt,
+		},
+		{
+			name:   "missing output",
+			stack:  cfntypes.Stack{Outputs: outputs, CreationTime: &createdAt},
+			output: "ImageTag",
+			err:    errStackOutputMissing,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotUpdatedAt, err := stackOutputVersion(tt.stack, tt.output)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("expected error %q, got %v", tt.err, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != tt.expected {
+				t.Errorf("expected version %q, got %q", tt.expected, got)
+			}
+
+			if gotUpdatedAt == nil || !gotUpdatedAt.Equal(tt.expectedUpdatedAt) {
+				t.Errorf("expected updated at %s, got %v", tt.expectedUpdatedAt, gotUpdatedAt)
+			}
+		})
+	}
+}
+
+func TestIsStackNotFound(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{
+			name:     "missing stack",
+			err:      &smithy.GenericAPIError{Code: "ValidationError", Message: "Stack with id payments-qa does not exist"},
+			expected: true,
+		},
+		{
+			name: "other validation error",
+			err:  &smithy.GenericAPIError{Code: "ValidationError", Message: "1 validation error detected"},
+		},
+		{
+			name: "access denied",
+			err:  &smithy.GenericAPIError{Code: "AccessDenied", Message: "not authorized"},
+		},
+		{
+			name: "not an API error",
+			err:  errUnknown,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isStackNotFound(tt.err); got != tt.expected {
+				t.Errorf("got %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package aws
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+)
+
+// describeServicesBatchSize is the maximum number of services that
+// DescribeServices accepts in a single call.
+const describeServicesBatchSize = 10
+
+// DiscoveredService is an ECS service found while discovering, along with the
+// names of the containers in its current task definition.
+type DiscoveredService struct {
+	Cluster    string
+	Service    string
+	Containers []string
+}
+
+// DiscoverServices lists all ECS services (across all clusters) that are
+// accessible via cfg.
+func DiscoverServices(ctx context.Context, cfg aws.Config, maxConcFetches int) ([]DiscoveredService, error) {
+	ecsClient := ecs.NewFromConfig(cfg)
+
+	var clusterArns []string
+	clustersPaginator := ecs.NewListClustersPaginator(ecsClient, &ecs.ListClustersInput{})
+	for clustersPaginator.HasMorePages() {
+		page, err := clustersPaginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		clusterArns = append(clusterArns, page.ClusterArns...)
+	}
+
+	type taskDefRef struct {
+		cluster string
+		service string
+		taskDef *string
+	}
+
+	var refs []taskDefRef
+	for _, clusterArn := range clusterArns {
+		serviceArns, err := listServiceArns(ctx, ecsClient, clusterArn)
+		if err != nil {
+			return nil, err
+		}
+
+		for start := 0; start < len(serviceArns); start += describeServicesBatchSize {
+			end := min(start+describeServicesBatchSize, len(serviceArns))
+			out, err := ecsClient.DescribeServices(ctx, &ecs.DescribeServicesInput{
+				Cluster:  &clusterArn,
+				Services: serviceArns[start:end],
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			for _, svc := range out.Services {
+				refs = append(refs, taskDefRef{
+					cluster: resourceName(clusterArn),
+					service: aws.ToString(svc.ServiceName),
+					taskDef: svc.TaskDefinition,
+				})
+			}
+		}
+	}
+
+	services := make([]DiscoveredService, len(refs))
+	errs := make([]error, len(refs))
+	semaphore := make(chan struct{}, maxConcFetches)
+	var wg sync.WaitGroup
+
+	for i, ref := range refs {
+		services[i] = DiscoveredService{Cluster: ref.cluster, Service: ref.service}
+		if ref.taskDef == nil {
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() {
+				<-semaphore
+			}()
+
+			out, err := ecsClient.DescribeTaskDefinition(ctx, &ecs.DescribeTaskDefinitionInput{TaskDefinition: ref.taskDef})
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			for _, containerDef := range out.TaskDefinition.ContainerDefinitions {
+				services[i].Containers = append(services[i].Containers, aws.ToString(containerDef.Name))
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return services, nil
+}
+
+// ListServices returns the names of all services in a cluster.
+func ListServices(ctx context.Context, cfg aws.Config, cluster string) ([]string, error) {
+	serviceArns, err := listServiceArns(ctx, ecs.NewFromConfig(cfg), cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(serviceArns))
+	for i, arn := range serviceArns {
+		names[i] = resourceName(arn)
+	}
+
+	return names, nil
+}
+
+func listServiceArns(ctx context.Context, ecsClient *ecs.Client, cluster string) ([]string, error) {
+	var serviceArns []string
+	paginator := ecs.NewListServicesPaginator(ecsClient, &ecs.ListServicesInput{Cluster: &cluster})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		serviceArns = append(serviceArns, page.ServiceArns...)
+	}
+
+	return serviceArns, nil
+}
+
+// resourceName returns the name at the end of an ARN like
+// "arn:aws:ecs:eu-central-1:123456789012:cluster/1brd-qa".
+func resourceName(arn string) string {
+	return arn[strings.LastIndex(arn, "/")+1:]
+}
@@ -0,0 +1,86 @@
+package aws
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/ssocreds"
+	"github.com/aws/smithy-go"
+	"github.com/dhth/ecsv/internal/types"
+)
+
+// ErrorHint returns a suggestion for fixing common credential and permission
+// errors, or an empty string if there's none. profile is the shared config
+// profile the credentials come from, if any.
+func ErrorHint(err error, profile string) string {
+	if err == nil {
+		return ""
+	}
+
+	var invalidTokenErr *ssocreds.InvalidTokenError
+	if errors.As(err, &invalidTokenErr) || strings.Contains(err.Error(), "cached SSO token is expired") {
+		if profile != "" {
+			return fmt.Sprintf("SSO session has expired; run \"aws sso login --profile %s\"", profile)
+		}
+		return "SSO session has expired; run \"aws sso login\""
+	}
+
+	var profileNotExistErr config.SharedConfigProfileNotExistError
+	if errors.As(err, &profileNotExistErr) {
+		return fmt.Sprintf("profile %q doesn't exist in your AWS config", profileNotExistErr.Profile)
+	}
+
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.ErrorCode() {
+		case "ExpiredToken", "ExpiredTokenException", "RequestExpired":
+			return "credentials have expired; refresh them and try again"
+		case "InvalidClientTokenId", "UnrecognizedClientException", "InvalidSignatureException":
+			return "credentials are invalid"
+		case "AccessDenied", "AccessDeniedException":
+			return "credentials are missing the required permissions"
+		}
+	}
+
+	if strings.Contains(err.Error(), "failed to refresh cached credentials") {
+		return "no valid credentials found"
+	}
+
+	return ""
+}
+
+// NewCredentialsError wraps an error encountered while setting up the AWS
+// config for a config entry.
+func NewCredentialsError(system types.VersionsConfig, err error) types.CredentialsError {
+	return types.CredentialsError{
+		Source: system.AWSConfigDescription(),
+		Hint:   ErrorHint(err, profileOf(system)),
+		Err:    err,
+	}
+}
+
+// wrapFetchError turns errors caused by a config entry's credentials (rather
+// than by the entry itself) into a types.CredentialsError, so that they can
+// be reported once for all entries that use the same credentials.
+func wrapFetchError(system types.VersionsConfig, err error) error {
+	hint := ErrorHint(err, profileOf(system))
+	if hint == "" {
+		return err
+	}
+
+	return types.CredentialsError{
+		Source: system.AWSConfigDescription(),
+		Hint:   hint,
+		Err:    err,
+	}
+}
+
+func profileOf(system types.VersionsConfig) string {
+	if system.AWSConfigSourceType != types.SharedCfgProfileType {
+		return ""
+	}
+
+	return system.AWSConfigSource
+}
@@ -0,0 +1,54 @@
+package aws
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/ssocreds"
+	"github.com/aws/smithy-go"
+)
+
+var errUnknown = errors.New("something went wrong")
+
+func TestErrorHint(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		profile  string
+		expected string
+	}{
+		{
+			name:     "expired sso session",
+			err:      fmt.Errorf("failed to refresh cached credentials: %w", &ssocreds.InvalidTokenError{}),
+			profile:  "qa",
+			expected: `SSO session has expired; run "aws sso login --profile qa"`,
+		},
+		{
+			name:     "missing profile",
+			err:      config.SharedConfigProfileNotExistError{Profile: "qa"},
+			profile:  "qa",
+			expected: `profile "qa" doesn't exist in your AWS config`,
+		},
+		{
+			name:     "access denied",
+			err:      &smithy.GenericAPIError{Code: "AccessDeniedException"},
+			expected: "credentials are missing the required permissions",
+		},
+		{
+			name: "unknown error",
+			err:  errUnknown,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ErrorHint(tt.err, tt.profile)
+
+			if got != tt.expected {
+				t.Errorf("got: %q, expected: %q", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	lambdatypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"github.com/dhth/ecsv/internal/logging"
+	"github.com/dhth/ecsv/internal/types"
+)
+
+// lambdaLastModifiedLayout is the layout of FunctionConfiguration.LastModified
+const lambdaLastModifiedLayout = "2006-01-02T15:04:05.000-0700"
+
+var (
+	errFunctionHasNoImage = errors.New("function is not deployed as a container image")
+	errFunctionTagMissing = errors.New("function doesn't have tag")
+	errFunctionEnvMissing = errors.New("function doesn't have environment variable")
+)
+
+// LambdaSource fetches versions of Lambda functions, from the image tag of
+// container based functions, a tag on the function, or an environment variable
+// of the function's version that an alias points to.
+type LambdaSource struct {
+	configs *ConfigCache
+}
+
+func NewLambdaSource(configs *ConfigCache) *LambdaSource {
+	return &LambdaSource{configs: configs}
+}
+
+func (s *LambdaSource) FetchVersion(ctx context.Context, system types.VersionsConfig) types.VersionResult {
+	cfg, err := s.configs.get(system)
+	if err != nil {
+		return types.VersionResult{
+			SystemKey: system.Key,
+			Env:       system.Env,
+			Err:       err,
+		}
+	}
+
+	return FetchFunctionVersion(ctx, system, cfg)
+}
+
+// FetchFunctionVersion fetches the version of a system that runs as a Lambda
+// function.
+func FetchFunctionVersion(ctx context.Context, system types.VersionsConfig, cfg aws.Config) types.VersionResult {
+	ctx = logging.WithSystem(ctx, system.Key, system.Env)
+
+	input := &lambda.GetFunctionInput{FunctionName: &system.FunctionName}
+	if system.FunctionAlias != "" {
+		input.Qualifier = &system.FunctionAlias
+	}
+
+	out, err := lambda.NewFromConfig(cfg).GetFunction(ctx, input)
+	if err != nil {
+		var notFoundErr *lambdatypes.ResourceNotFoundException
+		if errors.As(err, &notFoundErr) {
+			return types.VersionResult{
+				SystemKey: system.Key,
+				Env:       system.Env,
+				Found:     false,
+			}
+		}
+
+		return types.VersionResult{
+			SystemKey: system.Key,
+			Env:       system.Env,
+			Err:       wrapFetchError(system, err),
+		}
+	}
+
+	version, err := functionVersion(out, system.VersionFrom)
+	if err != nil {
+		return types.VersionResult{
+			SystemKey: system.Key,
+			Env:       system.Env,
+			Err:       err,
+		}
+	}
+
+	result := types.VersionResult{
+		Found:     true,
+		SystemKey: system.Key,
+		Env:       system.Env,
+		Version:   version,
+	}
+
+	if out.Configuration != nil && out.Configuration.LastModified != nil {
+		lastModified, err := time.Parse(lambdaLastModifiedLayout, *out.Configuration.LastModified)
+		if err == nil {
+			result.RegisteredAt = &lastModified
+		}
+	}
+
+	return result
+}
+
+// CheckGetFunction verifies that cfg's credentials are allowed to get a Lambda
+// function.
+func CheckGetFunction(ctx context.Context, cfg aws.Config, function string) error {
+	_, err := lambda.NewFromConfig(cfg).GetFunction(ctx, &lambda.GetFunctionInput{
+		FunctionName: &function,
+	})
+
+	return err
+}
+
+func functionVersion(out *lambda.GetFunctionOutput, versionFrom types.VersionFrom) (string, error) {
+	// empty values don't make for a version, so they're treated the same way
+	// as missing ones
+	switch versionFrom.Kind {
+	case types.ResourceTagVersion:
+		version := out.Tags[versionFrom.Name]
+		if version == "" {
+			return "", fmt.Errorf("%w: %s", errFunctionTagMissing, versionFrom.Name)
+		}
+		return version, nil
+	case types.EnvVarVersion:
+		var variables map[string]string
+		if out.Configuration != nil && out.Configuration.Environment != nil {
+			variables = out.Configuration.Environment.Variables
+		}
+		version := variables[versionFrom.Name]
+		if version == "" {
+			return "", fmt.Errorf("%w: %s", errFunctionEnvMissing, versionFrom.Name)
+		}
+		return version, nil
+	default:
+		if out.Code == nil || out.Code.ImageUri == nil {
+			return "", errFunctionHasNoImage
+		}
+		return imageTag(*out.Code.ImageUri), nil
+	}
+}
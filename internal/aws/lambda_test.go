@@ -0,0 +1,98 @@
+package aws
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	lambdatypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"github.com/dhth/ecsv/internal/types"
+)
+
+func TestFunctionVersion(t *testing.T) {
+	imageURI := "123456789012.dkr.ecr.eu-central-1.amazonaws.com/payments:1.4.2"
+	out := &lambda.GetFunctionOutput{
+		Code: &lambdatypes.FunctionCodeLocation{ImageUri: &imageURI},
+		Configuration: &lambdatypes.FunctionConfiguration{
+			Environment: &lambdatypes.EnvironmentResponse{
+				Variables: map[string]string{"APP_VERSION": "1.4.1", "BUILD_VERSION": ""},
+			},
+		},
+		Tags: map[string]string{"version": "1.4.0", "build": ""},
+	}
+
+	testCases := []struct {
+		name        string
+		out         *lambda.GetFunctionOutput
+		versionFrom types.VersionFrom
+		expected    string
+		err         error
+	}{
+		{
+			name:     "image tag",
+			out:      out,
+			expected: "1.4.2",
+		},
+		{
+			name:        "resource tag",
+			out:         out,
+			versionFrom: types.VersionFrom{Kind: types.ResourceTagVersion, Name: "version"},
+			expected:    "1.4.0",
+		},
+		{
+			name:        "environment variable",
+			out:         out,
+			versionFrom: types.VersionFrom{Kind: types.EnvVarVersion, Name: "APP_VERSION"},
+			expected:    "1.4.1",
+		},
+		{
+			name: "zip based function",
+			out:  &lambda.GetFunctionOutput{Code: &lambdatypes.FunctionCodeLocation{}},
+			err:  errFunctionHasNoImage,
+		},
+		{
+			name:        "missing tag",
+			out:         out,
+			versionFrom: types.VersionFrom{Kind: types.ResourceTagVersion, Name: "release"},
+			err:         errFunctionTagMissing,
+		},
+		{
+			name:        "missing environment variable",
+			out:         &lambda.GetFunctionOutput{},
+			versionFrom: types.VersionFrom{Kind: types.EnvVarVersion, Name: "APP_VERSION"},
+			err:         errFunctionEnvMissing,
+		},
+		{
+			name:        "empty tag",
+			out:         out,
+			versionFrom: types.VersionFrom{Kind: types.ResourceTagVersion, Name: "build"},
+			err:         errFunctionTagMissing,
+		},
+		{
+			name:        "empty environment variable",
+			out:         out,
+			versionFrom: types.VersionFrom{Kind: types.EnvVarVersion, Name: "BUILD_VERSION"},
+			err:         errFunctionEnvMissing,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := functionVersion(tt.out, tt.versionFrom)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("expected error %q, got %v", tt.err, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != tt.expected {
+				t.Errorf("expected version %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package aws
+
+import (
+	"context"
+	"time"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/smithy-go/middleware"
+	"github.com/dhth/ecsv/internal/logging"
+)
+
+// logCalls adds a middleware to an operation's stack that logs the operation,
+// once it's done (including retries).
+func logCalls(stack *middleware.Stack) error {
+	return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("ecsvLogCalls", func(
+		ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler,
+	) (middleware.InitializeOutput, middleware.Metadata, error) {
+		start := time.Now()
+		out, metadata, err := next.HandleInitialize(ctx, in)
+
+		call := logging.RemoteCall{
+			Service:   awsmiddleware.GetServiceID(ctx),
+			Operation: awsmiddleware.GetOperationName(ctx),
+			Duration:  time.Since(start),
+			Err:       err,
+		}
+		if results, ok := retry.GetAttemptResults(metadata); ok && len(results.Results) > 0 {
+			call.Retries = len(results.Results) - 1
+		}
+		logging.Call(ctx, call)
+
+		return out, metadata, err
+	}), middleware.After)
+}
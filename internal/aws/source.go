@@ -0,0 +1,70 @@
+package aws
+
+import (
+	"context"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/dhth/ecsv/internal/types"
+)
+
+// ConfigCache sets up the AWS config for each distinct AWS config key once,
+// and shares it between all config entries (and sources) that use it.
+type ConfigCache struct {
+	mu      sync.Mutex
+	entries map[string]*configEntry
+}
+
+type configEntry struct {
+	once sync.Once
+	cfg  aws.Config
+	err  error
+}
+
+func NewConfigCache() *ConfigCache {
+	return &ConfigCache{entries: make(map[string]*configEntry)}
+}
+
+// get returns the AWS config for system; errors are wrapped in a
+// types.CredentialsError.
+func (c *ConfigCache) get(system types.VersionsConfig) (aws.Config, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[system.AWSConfigKey()]
+	if !ok {
+		entry = &configEntry{}
+		c.entries[system.AWSConfigKey()] = entry
+	}
+	c.mu.Unlock()
+
+	entry.once.Do(func() {
+		entry.cfg, entry.err = GetConfig(system)
+		if entry.err != nil {
+			entry.err = NewCredentialsError(system, entry.err)
+		}
+	})
+
+	return entry.cfg, entry.err
+}
+
+// ECSSource fetches versions from the image tag of a container in the task
+// definition that an ECS service runs.
+type ECSSource struct {
+	configs *ConfigCache
+}
+
+func NewECSSource(configs *ConfigCache) *ECSSource {
+	return &ECSSource{configs: configs}
+}
+
+func (s *ECSSource) FetchVersion(ctx context.Context, system types.VersionsConfig) types.VersionResult {
+	cfg, err := s.configs.get(system)
+	if err != nil {
+		return types.VersionResult{
+			SystemKey: system.Key,
+			Env:       system.Env,
+			Err:       err,
+		}
+	}
+
+	return FetchSystemVersion(ctx, system, Config{Config: cfg})
+}
@@ -0,0 +1,82 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/dhth/ecsv/internal/logging"
+	"github.com/dhth/ecsv/internal/types"
+)
+
+// SSMSource fetches versions from the value of an SSM parameter, for
+// pipelines that record what they deployed in Parameter Store.
+type SSMSource struct {
+	configs *ConfigCache
+}
+
+func NewSSMSource(configs *ConfigCache) *SSMSource {
+	return &SSMSource{configs: configs}
+}
+
+func (s *SSMSource) FetchVersion(ctx context.Context, system types.VersionsConfig) types.VersionResult {
+	cfg, err := s.configs.get(system)
+	if err != nil {
+		return types.VersionResult{
+			SystemKey: system.Key,
+			Env:       system.Env,
+			Err:       err,
+		}
+	}
+
+	return FetchParameterVersion(ctx, system, cfg)
+}
+
+// FetchParameterVersion fetches the version of a system that's recorded in an
+// SSM parameter. SecureString parameters are decrypted.
+func FetchParameterVersion(ctx context.Context, system types.VersionsConfig, cfg aws.Config) types.VersionResult {
+	ctx = logging.WithSystem(ctx, system.Key, system.Env)
+
+	out, err := ssm.NewFromConfig(cfg).GetParameter(ctx, &ssm.GetParameterInput{
+		Name:           &system.ParameterName,
+		WithDecryption: aws.Bool(true),
+	})
+	if err != nil {
+		var notFoundErr *ssmtypes.ParameterNotFound
+		if errors.As(err, &notFoundErr) {
+			return types.VersionResult{
+				SystemKey: system.Key,
+				Env:       system.Env,
+				Found:     false,
+			}
+		}
+
+		return types.VersionResult{
+			SystemKey: system.Key,
+			Env:       system.Env,
+			Err:       wrapFetchError(system, err),
+		}
+	}
+
+	return types.VersionResult{
+		Found:        true,
+		SystemKey:    system.Key,
+		Env:          system.Env,
+		Version:      strings.TrimSpace(aws.ToString(out.Parameter.Value)),
+		RegisteredAt: out.Parameter.LastModifiedDate,
+	}
+}
+
+// CheckGetParameter verifies that cfg's credentials are allowed to get an SSM
+// parameter.
+func CheckGetParameter(ctx context.Context, cfg aws.Config, parameter string) error {
+	_, err := ssm.NewFromConfig(cfg).GetParameter(ctx, &ssm.GetParameterInput{
+		Name:           &parameter,
+		WithDecryption: aws.Bool(true),
+	})
+
+	return err
+}
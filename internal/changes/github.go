@@ -4,11 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"os"
 	"os/exec"
 	"strings"
 	"time"
 
+	"github.com/dhth/ecsv/internal/logging"
 	"github.com/dhth/ecsv/internal/types"
 	"github.com/google/go-github/v72/github"
 )
@@ -19,9 +21,14 @@ const transformPlaceholder = "{{version}}"
 
 func GetGHClient() (*github.Client, error) {
 	var zero *github.Client
+	var httpClient *http.Client
+	if logging.Enabled() {
+		httpClient = &http.Client{Transport: loggingTransport{base: http.DefaultTransport}}
+	}
+
 	tokenFromEnv := os.Getenv("GH_TOKEN")
 	if tokenFromEnv != "" {
-		return github.NewClient(nil).WithAuthToken(os.Getenv("GH_TOKEN")), nil
+		return github.NewClient(httpClient).WithAuthToken(os.Getenv("GH_TOKEN")), nil
 	}
 
 	tokenFromGH, err := getTokenFromGH()
@@ -29,10 +36,15 @@ func GetGHClient() (*github.Client, error) {
 		return zero, err
 	}
 
-	client := github.NewClient(nil).WithAuthToken(tokenFromGH)
+	client := github.NewClient(httpClient).WithAuthToken(tokenFromGH)
 	return client, nil
 }
 
+// Host returns the GitHub host a client talks to, eg. "github.com".
+func Host(client *github.Client) string {
+	return strings.TrimPrefix(client.BaseURL.Hostname(), "api.")
+}
+
 func getTokenFromGH() (string, error) {
 	var zero string
 	cmd := exec.Command("gh", "auth", "token")
@@ -45,13 +57,15 @@ func getTokenFromGH() (string, error) {
 }
 
 func FetchChanges(
+	ctx context.Context,
 	client *github.Client,
 	config types.ChangesConfig,
 	baseRef,
 	headRef string,
 ) types.ChangesResult {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
+	ctx = logging.WithSystem(ctx, config.SystemKey, fmt.Sprintf("%s..%s", config.Base, config.Head))
 
 	options := github.ListOptions{
 		Page:    0,
@@ -114,3 +128,14 @@ func FetchChanges(
 		DiffURL: fmt.Sprintf("https://github.com/%s/%s/compare/%s...%s", config.Owner, config.Repo, baseRefToUse, headRefToUse),
 	}
 }
+
+// GetAuthenticatedUser returns the login of the user that client is
+// authenticated as.
+func GetAuthenticatedUser(ctx context.Context, client *github.Client) (string, error) {
+	user, _, err := client.Users.Get(ctx, "")
+	if err != nil {
+		return "", err
+	}
+
+	return user.GetLogin(), nil
+}
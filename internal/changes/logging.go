@@ -0,0 +1,37 @@
+package changes
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/dhth/ecsv/internal/logging"
+)
+
+// loggingTransport logs every request made to GitHub.
+type loggingTransport struct {
+	base http.RoundTripper
+}
+
+func (t loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+
+	call := logging.RemoteCall{
+		Service:   "GitHub",
+		Operation: req.Method + " " + req.URL.Path,
+		Duration:  time.Since(start),
+		Err:       err,
+	}
+	if err == nil && resp.StatusCode >= http.StatusBadRequest {
+		call.Err = httpStatusError(resp.Status)
+	}
+	logging.Call(req.Context(), call)
+
+	return resp, err
+}
+
+type httpStatusError string
+
+func (e httpStatusError) Error() string {
+	return "unexpected status: " + string(e)
+}
@@ -0,0 +1,258 @@
+package check
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dhth/ecsv/internal/aws"
+	"github.com/dhth/ecsv/internal/changes"
+	"github.com/dhth/ecsv/internal/drift"
+	"github.com/dhth/ecsv/internal/endpoint"
+	"github.com/dhth/ecsv/internal/kube"
+	"github.com/dhth/ecsv/internal/policy"
+	"github.com/dhth/ecsv/internal/types"
+	"github.com/google/go-github/v72/github"
+)
+
+var ErrNoVersionSource = errors.New("no version source available")
+
+// VersionSource fetches the version of a system in an env from a backend, eg.
+// ECS. Errors are reported via the result's Err.
+type VersionSource interface {
+	FetchVersion(ctx context.Context, system types.VersionsConfig) types.VersionResult
+}
+
+// DefaultSources returns the version sources that ecsv supports, keyed by the
+// kind of source they handle. Sources backed by AWS share the AWS configs
+// they set up.
+func DefaultSources() map[types.SourceKind]VersionSource {
+	awsConfigs := aws.NewConfigCache()
+
+	return map[types.SourceKind]VersionSource{
+		types.ECSSource:        aws.NewECSSource(awsConfigs),
+		types.LambdaSource:     aws.NewLambdaSource(awsConfigs),
+		types.KubernetesSource: kube.NewSource(),
+		types.HTTPSource:       endpoint.NewSource(),
+		types.SSMSource:        aws.NewSSMSource(awsConfigs),
+		types.CFNSource:        aws.NewCFNSource(awsConfigs),
+	}
+}
+
+// Options determines how a check is run.
+type Options struct {
+	// MaxConcurrentFetches is the global limit on concurrent fetches
+	MaxConcurrentFetches int
+	// Sources are used for fetching versions, keyed by the kind of source
+	// they handle; DefaultSources is used if not set
+	Sources map[types.SourceKind]VersionSource
+	// GitHubClient is used for fetching commits between versions; changes
+	// are only fetched if it's set
+	GitHubClient *github.Client
+	// OnResult, if set, is called with every version result as soon as it's
+	// available. It's called from a single goroutine.
+	OnResult func(types.VersionResult)
+}
+
+// Result holds everything gathered during a check.
+type Result struct {
+	Versions   map[string]map[string]types.VersionResult
+	Drift      map[string]drift.Analysis
+	Changes    []types.ChangesResult
+	Violations []policy.Violation
+	Timings    []types.FetchTiming
+}
+
+// SystemKeys returns the keys of the systems in config, in the order they
+// first appear in.
+func SystemKeys(config types.Config) []string {
+	var keys []string
+	seen := make(map[string]bool)
+	for _, v := range config.Versions {
+		if !seen[v.Key] {
+			keys = append(keys, v.Key)
+			seen[v.Key] = true
+		}
+	}
+
+	return keys
+}
+
+// Run fetches the versions of all systems in config, analyses them for drift
+// and policy violations, and fetches the changes between versions if a GitHub
+// client is provided. If ctx is done before all fetches finish, the pending
+// ones result in ctx's error.
+func Run(ctx context.Context, config types.Config, envSequence []string, opts Options) Result {
+	versionResults := make(map[string]map[string]types.VersionResult)
+	resultChannel := make(chan types.VersionResult)
+
+	onResult := func(r types.VersionResult) {
+		if opts.OnResult != nil {
+			opts.OnResult(r)
+		}
+	}
+
+	sources := opts.Sources
+	if sources == nil {
+		sources = DefaultSources()
+	}
+
+	limiter := newLimiter(opts.MaxConcurrentFetches)
+	var wg sync.WaitGroup
+
+	for _, s := range config.Versions {
+		if versionResults[s.Key] == nil {
+			versionResults[s.Key] = make(map[string]types.VersionResult)
+		}
+		versionResults[s.Key][s.Env] = types.VersionResult{}
+
+		source, ok := sources[s.Source]
+		if !ok {
+			versionResults[s.Key][s.Env] = types.VersionResult{
+				SystemKey: s.Key,
+				Env:       s.Env,
+				Err:       fmt.Errorf("%w: %s", ErrNoVersionSource, s.Source),
+			}
+			onResult(versionResults[s.Key][s.Env])
+			continue
+		}
+
+		limiter.addSource(s.SourceKey(), s.SourceDescription(), config.Concurrency.ForAWSConfig(s))
+		wg.Add(1)
+
+		go func(system types.VersionsConfig) {
+			defer wg.Done()
+			var result types.VersionResult
+			err := limiter.run(ctx, system.SourceKey(), func() {
+				result = source.FetchVersion(ctx, system)
+			})
+			if err != nil {
+				result = types.VersionResult{
+					SystemKey: system.Key,
+					Env:       system.Env,
+					Err:       err,
+				}
+			}
+			resultChannel <- result
+		}(s)
+	}
+
+	go func() {
+		wg.Wait()
+		close(resultChannel)
+	}()
+
+	for r := range resultChannel {
+		versionResults[r.SystemKey][r.Env] = r
+		onResult(r)
+	}
+
+	now := time.Now()
+	driftResults := make(map[string]drift.Analysis)
+	for systemKey, results := range versionResults {
+		driftResults[systemKey] = drift.Analyze(envSequence, results, config.VersionPatterns[systemKey], now)
+	}
+
+	var changesResults []types.ChangesResult
+	if opts.GitHubClient != nil && len(config.Changes) > 0 {
+		changesResults = fetchChanges(ctx, config, versionResults, opts.GitHubClient, limiter)
+	}
+
+	return Result{
+		Versions:   versionResults,
+		Drift:      driftResults,
+		Changes:    changesResults,
+		Violations: policy.Evaluate(config, SystemKeys(config), envSequence, versionResults, now),
+		Timings:    limiter.timings(),
+	}
+}
+
+func fetchChanges(
+	ctx context.Context,
+	config types.Config,
+	versionResults map[string]map[string]types.VersionResult,
+	ghClient *github.Client,
+	limiter *limiter,
+) []types.ChangesResult {
+	changesResultChan := make(chan types.ChangesResult)
+
+	//nolint:prealloc
+	var changesResults []types.ChangesResult
+
+	ghHost := changes.Host(ghClient)
+	ghSourceKey := "github:" + ghHost
+	limiter.addSource(ghSourceKey, fmt.Sprintf("GitHub (%s)", ghHost), config.Concurrency.GitHub[ghHost])
+	var changesWg sync.WaitGroup
+
+	for _, changesConfig := range config.Changes {
+		vrm, ok := versionResults[changesConfig.SystemKey]
+
+		// TODO: handle these conditions related to inconsistent state
+		if !ok {
+			continue
+		}
+
+		vrBase, ok := vrm[changesConfig.Base]
+		if !ok {
+			continue
+		}
+
+		if vrBase.Err != nil {
+			continue
+		}
+
+		vrHead, ok := vrm[changesConfig.Head]
+		if !ok {
+			continue
+		}
+
+		if vrHead.Err != nil {
+			continue
+		}
+
+		if vrBase.Version == vrHead.Version {
+			continue
+		}
+
+		changesWg.Add(1)
+		go func(baseRef, headRef string) {
+			defer changesWg.Done()
+			var result types.ChangesResult
+			err := limiter.run(ctx, ghSourceKey, func() {
+				result = changes.FetchChanges(
+					ctx,
+					ghClient,
+					changesConfig,
+					baseRef,
+					headRef)
+			})
+			if err != nil {
+				result = types.ChangesResult{
+					Config: changesConfig,
+					Error:  err,
+				}
+			}
+			changesResultChan <- result
+		}(vrBase.Version,
+			vrHead.Version,
+		)
+	}
+
+	go func() {
+		changesWg.Wait()
+		close(changesResultChan)
+	}()
+
+	for r := range changesResultChan {
+		changesResults = append(changesResults, r)
+	}
+
+	sort.Slice(changesResults, func(i, j int) bool {
+		return changesResults[i].Config.SystemKey < changesResults[j].Config.SystemKey
+	})
+
+	return changesResults
+}
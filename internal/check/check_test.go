@@ -0,0 +1,34 @@
+package check
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dhth/ecsv/internal/types"
+)
+
+func TestRunReportsMissingVersionSource(t *testing.T) {
+	config := types.Config{
+		Versions: []types.VersionsConfig{
+			{Key: "service-a", Env: "qa", Source: types.ECSSource},
+		},
+	}
+
+	var handled []types.VersionResult
+	result := Run(context.Background(), config, []string{"qa"}, Options{
+		MaxConcurrentFetches: 1,
+		Sources:              map[types.SourceKind]VersionSource{},
+		OnResult: func(r types.VersionResult) {
+			handled = append(handled, r)
+		},
+	})
+
+	got := result.Versions["service-a"]["qa"]
+	if !errors.Is(got.Err, ErrNoVersionSource) {
+		t.Errorf("got error %v, expected %q", got.Err, ErrNoVersionSource)
+	}
+	if len(handled) != 1 {
+		t.Errorf("result handler was called %d times, expected 1", len(handled))
+	}
+}
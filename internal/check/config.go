@@ -0,0 +1,51 @@
+package check
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dhth/ecsv/internal/types"
+)
+
+var (
+	ErrConfigIsInvalid     = errors.New("invalid config provided")
+	errEnvNotInEnvSequence = errors.New("env not present in env-sequence")
+)
+
+// ReadConfig parses ecsv's config, and checks that all envs are present in
+// env-sequence. It returns env-sequence (with filtered out envs removed) along
+// with the parsed config.
+func ReadConfig(ecsvConfig types.ECSVConfig, filters types.Filters) ([]string, types.Config, error) {
+	var zero types.Config
+
+	config, errors := ecsvConfig.Parse(filters)
+	if len(errors) > 0 {
+		errMsgs := make([]string, len(errors))
+		for i, err := range errors {
+			errMsgs[i] = fmt.Sprintf("- %s", err.Error())
+		}
+		return nil, zero, fmt.Errorf("%w; errors:\n%s", ErrConfigIsInvalid, strings.Join(errMsgs, "\n"))
+	}
+
+	// assert that all envs are present in env-sequence
+	seqMap := make(map[string]bool)
+	for _, s := range ecsvConfig.EnvSequence {
+		seqMap[s] = true
+	}
+
+	for _, vc := range config.Versions {
+		if !seqMap[vc.Env] {
+			return nil, zero, fmt.Errorf("%w: %s", errEnvNotInEnvSequence, vc.Env)
+		}
+	}
+
+	envSequence := make([]string, 0, len(ecsvConfig.EnvSequence))
+	for _, env := range ecsvConfig.EnvSequence {
+		if filters.IncludesEnv(env) {
+			envSequence = append(envSequence, env)
+		}
+	}
+
+	return envSequence, config, nil
+}
@@ -0,0 +1,126 @@
+package check
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dhth/ecsv/internal/types"
+)
+
+// limiter caps the number of fetches that run at the same time, both overall
+// and per source (eg. per AWS config), so that a source that's being
+// throttled can be limited without slowing down the others. It also keeps
+// track of how long fetches take per source.
+type limiter struct {
+	global  chan struct{}
+	mu      sync.Mutex
+	sources map[string]*fetchSource
+	order   []string
+}
+
+type fetchSource struct {
+	slots  chan struct{}
+	timing types.FetchTiming
+}
+
+func newLimiter(maxConcFetches int) *limiter {
+	return &limiter{
+		global:  make(chan struct{}, maxConcFetches),
+		sources: make(map[string]*fetchSource),
+	}
+}
+
+// addSource registers a source, described by label. A limit of 0 means that
+// only the global limit applies to the source.
+func (l *limiter) addSource(key, label string, limit int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.sources[key]; ok {
+		return
+	}
+
+	source := &fetchSource{timing: types.FetchTiming{Source: label, Limit: limit}}
+	if limit > 0 {
+		source.slots = make(chan struct{}, limit)
+	}
+	l.sources[key] = source
+	l.order = append(l.order, key)
+}
+
+// run calls fetch once both the source's and the global limits allow it, or
+// returns ctx's error if it's done before that. Sources need to have been added
+// beforehand.
+func (l *limiter) run(ctx context.Context, key string, fetch func()) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	source := l.sources[key]
+	l.mu.Unlock()
+	queuedAt := time.Now()
+
+	// the source's slot is acquired first, so that fetches waiting on a
+	// limited source don't hold up global slots
+	if source.slots != nil {
+		select {
+		case source.slots <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		defer func() {
+			<-source.slots
+		}()
+	}
+	select {
+	case l.global <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() {
+		<-l.global
+	}()
+
+	start := time.Now()
+	fetch()
+	end := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	t := &source.timing
+	if t.Fetches == 0 || start.Before(t.FirstStart) {
+		t.FirstStart = start
+	}
+	if end.After(t.LastEnd) {
+		t.LastEnd = end
+	}
+	t.Fetches++
+	t.Waited += start.Sub(queuedAt)
+	t.Total += end.Sub(start)
+	t.Slowest = max(t.Slowest, end.Sub(start))
+
+	return nil
+}
+
+// timings returns the timings of sources that had at least one fetch, slowest
+// first.
+func (l *limiter) timings() []types.FetchTiming {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var timings []types.FetchTiming
+	for _, key := range l.order {
+		if t := l.sources[key].timing; t.Fetches > 0 {
+			timings = append(timings, t)
+		}
+	}
+
+	sort.SliceStable(timings, func(i, j int) bool {
+		return timings[i].Elapsed() > timings[j].Elapsed()
+	})
+
+	return timings
+}
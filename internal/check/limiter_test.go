@@ -0,0 +1,132 @@
+package check
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLimiterRespectsLimits(t *testing.T) {
+	testCases := []struct {
+		name        string
+		global      int
+		sourceLimit int
+		expectedMax int32
+	}{
+		{name: "global limit", global: 2, sourceLimit: 0, expectedMax: 2},
+		// each of the two sources can run one fetch at a time
+		{name: "source limit lower than global", global: 4, sourceLimit: 1, expectedMax: 2},
+		{name: "source limit higher than global", global: 2, sourceLimit: 5, expectedMax: 2},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newLimiter(tt.global)
+			var running, maxRunning atomic.Int32
+			var wg sync.WaitGroup
+
+			for i := range 10 {
+				// sources are added while fetches for earlier ones are running
+				key := fmt.Sprintf("source-%d", i%2)
+				l.addSource(key, key, tt.sourceLimit)
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					err := l.run(context.Background(), key, func() {
+						n := running.Add(1)
+						for {
+							m := maxRunning.Load()
+							if n <= m || maxRunning.CompareAndSwap(m, n) {
+								break
+							}
+						}
+						time.Sleep(5 * time.Millisecond)
+						running.Add(-1)
+					})
+					if err != nil {
+						t.Errorf("unexpected error: %v", err)
+					}
+				}()
+			}
+			wg.Wait()
+
+			if got := maxRunning.Load(); got > tt.expectedMax {
+				t.Errorf("got %d concurrent fetches, expected at most %d", got, tt.expectedMax)
+			}
+		})
+	}
+}
+
+func TestLimiterTimings(t *testing.T) {
+	l := newLimiter(4)
+	l.addSource("fast", "fast", 0)
+	l.addSource("slow", "slow", 0)
+	l.addSource("unused", "unused", 0)
+
+	var wg sync.WaitGroup
+	for _, s := range []struct {
+		key   string
+		delay time.Duration
+	}{
+		{"fast", time.Millisecond},
+		{"fast", time.Millisecond},
+		{"slow", 20 * time.Millisecond},
+	} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = l.run(context.Background(), s.key, func() {
+				time.Sleep(s.delay)
+			})
+		}()
+	}
+	wg.Wait()
+
+	timings := l.timings()
+	if len(timings) != 2 {
+		t.Fatalf("expected timings for 2 sources, got: %+v", timings)
+	}
+	if timings[0].Source != "slow" || timings[0].Fetches != 1 {
+		t.Errorf("expected slow source first with 1 fetch, got: %+v", timings[0])
+	}
+	if timings[1].Source != "fast" || timings[1].Fetches != 2 {
+		t.Errorf("expected fast source second with 2 fetches, got: %+v", timings[1])
+	}
+}
+
+func TestLimiterStopsWhenContextIsDone(t *testing.T) {
+	l := newLimiter(1)
+	l.addSource("source", "source", 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go func() {
+		_ = l.run(context.Background(), "source", func() {
+			close(started)
+			<-release
+		})
+	}()
+	<-started
+
+	errs := make(chan error, 1)
+	called := false
+	go func() {
+		errs <- l.run(ctx, "source", func() {
+			called = true
+		})
+	}()
+	cancel()
+
+	if err := <-errs; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got: %v", err)
+	}
+	close(release)
+	if called {
+		t.Error("fetch was called after the context was done")
+	}
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sync"
+
+	"github.com/dhth/ecsv/internal/audit"
+	"github.com/dhth/ecsv/internal/aws"
+	"github.com/dhth/ecsv/internal/types"
+)
+
+var (
+	ErrConfigDriftFound = errors.New("config doesn't match what exists in AWS")
+	errAuditIncomplete  = errors.New("couldn't check all clusters")
+)
+
+// runAudit audits the clusters referred to by config. Coverage is determined
+// against fullConfig (ie, the config before filters were applied), so that
+// services of filtered out systems aren't reported as uncovered.
+func runAudit(config, fullConfig types.Config, ignore *regexp.Regexp, maxConcFetches int) error {
+	// only ECS services can be audited
+	versions := ecsVersions(config)
+
+	awsConfigs := make(map[string]aws.Config)
+	for _, v := range versions {
+		if _, ok := awsConfigs[v.AWSConfigKey()]; ok {
+			continue
+		}
+		cfg, err := aws.GetConfig(v)
+		awsConfigs[v.AWSConfigKey()] = aws.Config{
+			Config: cfg,
+			Err:    err,
+		}
+	}
+
+	clusters := audit.Clusters(versions)
+	existing := make(map[audit.Cluster]audit.ClusterServices)
+	var mu sync.Mutex
+	semaphore := make(chan struct{}, maxConcFetches)
+	var wg sync.WaitGroup
+
+	for _, cluster := range clusters {
+		awsConfig := awsConfigs[cluster.AWSConfigKey]
+		if awsConfig.Err != nil {
+			existing[cluster] = audit.ClusterServices{Err: awsConfig.Err}
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() {
+				<-semaphore
+			}()
+
+			services, err := aws.ListServices(context.Background(), awsConfig.Config, cluster.Name)
+
+			mu.Lock()
+			existing[cluster] = audit.ClusterServices{Services: services, Err: err}
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	report := audit.Compare(versions, ecsVersions(fullConfig), existing, ignore)
+	fmt.Print(report.String())
+
+	if report.HasFindings() {
+		return ErrConfigDriftFound
+	}
+
+	if len(report.Errors) > 0 {
+		return errAuditIncomplete
+	}
+
+	return nil
+}
+
+func ecsVersions(config types.Config) []types.VersionsConfig {
+	return slices.DeleteFunc(slices.Clone(config.Versions), func(v types.VersionsConfig) bool {
+		return v.Source != types.ECSSource
+	})
+}
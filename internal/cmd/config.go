@@ -3,48 +3,186 @@ package cmd
 import (
 	"errors"
 	"fmt"
-	"regexp"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/dhth/ecsv/internal/types"
+	"github.com/dhth/ecsv/internal/utils"
 
 	"gopkg.in/yaml.v3"
 )
 
 var (
-	errConfigIsInvalidYAML = errors.New("config file is not valid YAML")
-	errConfigIsInvalid     = errors.New("invalid config provided")
-	errEnvNotInEnvSequence = errors.New("env not present in env-sequence")
+	errConfigIsInvalidYAML    = errors.New("config file is not valid YAML")
+	errNoConfigFilesInDir     = errors.New("config directory doesn't contain any YAML files")
+	errIncludePatternInvalid  = errors.New("include pattern is invalid")
+	errIncludeMatchedNothing  = errors.New("include didn't match any files")
+	errCouldntMergeConfigFile = errors.New("couldn't merge config file")
+	errConfigHasProblems      = errors.New("config has problems")
 )
 
-func readConfig(configBytes []byte, keyRegex *regexp.Regexp) ([]string, types.Config, error) {
-	var zero types.Config
-	ecsvConfig := types.ECSVConfig{}
-	err := yaml.Unmarshal(configBytes, &ecsvConfig)
+// configLoader reads the YAML files that make up ecsv's config; these are either
+// provided directly, present in the provided directory, or included by another
+// config file.
+type configLoader struct {
+	homeDir string
+	visited map[string]bool
+	files   []types.ConfigDocument
+}
+
+// loadConfigFiles reads the config file (or directory) at path, along with all
+// the files it includes.
+func loadConfigFiles(path, homeDir string) ([]types.ConfigDocument, error) {
+	loader := configLoader{
+		homeDir: homeDir,
+		visited: make(map[string]bool),
+	}
+
+	if err := loader.load(path); err != nil {
+		return nil, err
+	}
+
+	return loader.files, nil
+}
+
+func (l *configLoader) load(path string) error {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("%w: %s", errConfigFileDoesntExist, path)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %w", errCouldntReadConfigFile, err)
+	}
+
+	if info.IsDir() {
+		return l.loadDir(path)
+	}
+
+	if !isYAMLFile(path) {
+		return fmt.Errorf("%w: %s", errConfigFileNotYAML, path)
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errCouldntReadConfigFile, err)
+	}
+	if l.visited[absPath] {
+		return nil
+	}
+	l.visited[absPath] = true
+
+	configBytes, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errCouldntReadConfigFile, err)
+	}
+
+	var root yaml.Node
+	err = yaml.Unmarshal(configBytes, &root)
+	if err != nil {
+		return fmt.Errorf("%w (%s): %s", errConfigIsInvalidYAML, path, err.Error())
+	}
+
+	var ecsvConfig types.ECSVConfig
+	if root.Kind != 0 {
+		err = root.Decode(&ecsvConfig)
+		if err != nil {
+			return fmt.Errorf("%w (%s): %s", errConfigIsInvalidYAML, path, err.Error())
+		}
+	}
+
+	l.files = append(l.files, types.ConfigDocument{
+		Path:   path,
+		Root:   &root,
+		Config: ecsvConfig,
+	})
+
+	for _, include := range ecsvConfig.Include {
+		includePath := utils.ExpandTilde(include, l.homeDir)
+		if !filepath.IsAbs(includePath) {
+			includePath = filepath.Join(filepath.Dir(path), includePath)
+		}
+
+		matches, err := filepath.Glob(includePath)
+		if err != nil {
+			return fmt.Errorf("%w (%s): %s", errIncludePatternInvalid, path, err.Error())
+		}
+		if len(matches) == 0 {
+			return fmt.Errorf("%w (%s): %s", errIncludeMatchedNothing, path, include)
+		}
+
+		for _, match := range matches {
+			if err := l.load(match); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (l *configLoader) loadDir(dir string) error {
+	entries, err := os.ReadDir(dir)
 	if err != nil {
-		return nil, zero, fmt.Errorf("%w: %s", errConfigIsInvalidYAML, err.Error())
+		return fmt.Errorf("%w: %w", errCouldntReadConfigFile, err)
 	}
 
-	config, errors := ecsvConfig.Parse(keyRegex)
-	if len(errors) > 0 {
-		errMsgs := make([]string, len(errors))
-		for i, err := range errors {
-			errMsgs[i] = fmt.Sprintf("- %s", err.Error())
+	var paths []string
+	for _, entry := range entries {
+		if entry.IsDir() || !isYAMLFile(entry.Name()) {
+			continue
 		}
-		return nil, zero, fmt.Errorf("%w; errors:\n%s", errConfigIsInvalid, strings.Join(errMsgs, "\n"))
+		paths = append(paths, filepath.Join(dir, entry.Name()))
 	}
 
-	// assert that all envs are present in env-sequence
-	seqMap := make(map[string]bool)
-	for _, s := range ecsvConfig.EnvSequence {
-		seqMap[s] = true
+	if len(paths) == 0 {
+		return fmt.Errorf("%w: %s", errNoConfigFilesInDir, dir)
 	}
 
-	for _, vc := range config.Versions {
-		if !seqMap[vc.Env] {
-			return nil, zero, fmt.Errorf("%w: %s", errEnvNotInEnvSequence, vc.Env)
+	slices.Sort(paths)
+	for _, path := range paths {
+		if err := l.load(path); err != nil {
+			return err
 		}
 	}
 
-	return ecsvConfig.EnvSequence, config, nil
+	return nil
+}
+
+func isYAMLFile(path string) bool {
+	ext := filepath.Ext(path)
+	return ext == ".yml" || ext == ".yaml"
+}
+
+func mergeConfigFiles(files []types.ConfigDocument) (types.ECSVConfig, error) {
+	var merged types.ECSVConfig
+	for _, file := range files {
+		var err error
+		merged, err = merged.Merge(file.Config)
+		if err != nil {
+			return merged, fmt.Errorf("%w (%s): %w", errCouldntMergeConfigFile, file.Path, err)
+		}
+	}
+
+	return merged, nil
+}
+
+func validateConfig(files []types.ConfigDocument) error {
+	issues := types.Validate(files)
+	if len(issues) > 0 {
+		for _, issue := range issues {
+			fmt.Fprintln(os.Stderr, issue.String())
+		}
+		fmt.Fprintln(os.Stderr)
+		return fmt.Errorf("%w: %d found", errConfigHasProblems, len(issues))
+	}
+
+	paths := make([]string, len(files))
+	for i, file := range files {
+		paths[i] = file.Path
+	}
+	fmt.Printf("config is valid (files: %s)\n", strings.Join(paths, ", "))
+
+	return nil
 }
@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/dhth/ecsv/internal/aws"
+	"github.com/dhth/ecsv/internal/discover"
+	"github.com/dhth/ecsv/internal/types"
+)
+
+var (
+	errNoProfilesProvided     = errors.New("no profiles provided")
+	errProfileIsInvalid       = errors.New("profile is invalid")
+	errNoRegionForProfile     = errors.New("no region provided for profile")
+	errCouldntDiscoverForEnv  = errors.New("couldn't discover services")
+	errNoServicesDiscovered   = errors.New("no services discovered")
+	errDuplicateEnvProvided   = errors.New("env provided more than once")
+	errOutputFileExists       = errors.New("output file already exists")
+	errCouldntWriteOutputFile = errors.New("couldn't write output file")
+)
+
+// parseDiscoveryTarget parses a target in the form "[env=]profile[@region]".
+// The env defaults to the name of the profile, and the region to
+// defaultRegion.
+func parseDiscoveryTarget(value, defaultRegion string) (discover.Target, error) {
+	var target discover.Target
+
+	profile := value
+	if env, rest, ok := strings.Cut(value, "="); ok {
+		target.Env = env
+		profile = rest
+	}
+
+	profile, region, ok := strings.Cut(profile, "@")
+	if !ok {
+		region = defaultRegion
+	}
+
+	target.Profile = profile
+	target.Region = region
+	if target.Env == "" {
+		target.Env = profile
+	}
+
+	if strings.TrimSpace(target.Profile) == "" || strings.TrimSpace(target.Env) == "" {
+		return target, fmt.Errorf("%w: %q; expected format: [env=]profile[@region]", errProfileIsInvalid, value)
+	}
+
+	if target.Region == "" {
+		return target, fmt.Errorf("%w: %q; provide one via --region or [env=]profile@region", errNoRegionForProfile, value)
+	}
+
+	return target, nil
+}
+
+func runDiscover(profiles []string, defaultRegion, outputPath string, maxConcFetches int) error {
+	if len(profiles) == 0 {
+		return errNoProfilesProvided
+	}
+
+	if outputPath != "" {
+		if _, err := os.Stat(outputPath); err == nil {
+			return fmt.Errorf("%w: %s", errOutputFileExists, outputPath)
+		}
+	}
+
+	targets := make([]discover.Target, len(profiles))
+	seenEnvs := make(map[string]bool)
+	for i, p := range profiles {
+		target, err := parseDiscoveryTarget(p, defaultRegion)
+		if err != nil {
+			return err
+		}
+		if seenEnvs[target.Env] {
+			return fmt.Errorf("%w: %s", errDuplicateEnvProvided, target.Env)
+		}
+		seenEnvs[target.Env] = true
+		targets[i] = target
+	}
+
+	var results []discover.EnvServices
+	var numServices int
+	for _, target := range targets {
+		cfg, err := aws.GetConfig(types.VersionsConfig{
+			AWSConfigSourceType: types.SharedCfgProfileType,
+			AWSConfigSource:     target.Profile,
+			AWSRegion:           target.Region,
+		})
+		if err != nil {
+			return fmt.Errorf("%w (env: %s): %w", errCouldntDiscoverForEnv, target.Env, err)
+		}
+
+		services, err := aws.DiscoverServices(context.Background(), cfg, maxConcFetches)
+		if err != nil {
+			return fmt.Errorf("%w (env: %s): %w", errCouldntDiscoverForEnv, target.Env, err)
+		}
+
+		fmt.Fprintf(os.Stderr, "%s: found %d services\n", target.Env, len(services))
+		numServices += len(services)
+		results = append(results, discover.EnvServices{Target: target, Services: services})
+	}
+
+	if numServices == 0 {
+		return errNoServicesDiscovered
+	}
+
+	draft, err := discover.Draft(results)
+	if err != nil {
+		return err
+	}
+
+	if outputPath == "" {
+		fmt.Print(string(draft))
+		return nil
+	}
+
+	err = os.WriteFile(outputPath, draft, 0o644)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errCouldntWriteOutputFile, err)
+	}
+
+	fmt.Fprintf(os.Stderr, "wrote draft config to %s; run \"ecsv config validate -c %s\" after reviewing it\n", outputPath, outputPath)
+
+	return nil
+}
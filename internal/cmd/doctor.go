@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/dhth/ecsv/internal/doctor"
+	"github.com/dhth/ecsv/internal/types"
+	"github.com/dhth/ecsv/internal/ui"
+)
+
+var errCredentialChecksFailed = errors.New("some credentials don't work")
+
+func runDoctor(config types.Config, tableStyle types.TableStyle, maxConcFetches int) error {
+	checks := doctor.CheckAWS(config.Versions, maxConcFetches)
+	if len(config.Changes) > 0 {
+		checks = append(checks, doctor.CheckGitHub())
+	}
+
+	output, err := ui.GetDoctorOutput(checks, tableStyle)
+	if err != nil {
+		return err
+	}
+	fmt.Print(output)
+
+	for _, c := range checks {
+		if !c.OK() {
+			return errCredentialChecksFailed
+		}
+	}
+
+	return nil
+}
@@ -6,9 +6,16 @@ import (
 	"github.com/dhth/ecsv/internal/ui"
 )
 
+const (
+	policiesViolatedExitCode = 2
+	configDriftFoundExitCode = 2
+	interruptedExitCode      = 130
+)
+
 type ErrorFollowUp struct {
 	IsUnexpected bool
 	Message      string
+	ExitCode     int
 }
 
 func GetErrorFollowUp(err error) (ErrorFollowUp, bool) {
@@ -18,6 +25,12 @@ func GetErrorFollowUp(err error) (ErrorFollowUp, bool) {
 		return unexpectedErr("")
 	} else if errors.Is(err, ui.ErrCouldntParseHTMLTemplate) {
 		return expectedErr("Maybe take a look at ecsv's built in template (on GitHub)")
+	} else if errors.Is(err, ErrPoliciesViolated) {
+		return ErrorFollowUp{ExitCode: policiesViolatedExitCode}, true
+	} else if errors.Is(err, ErrConfigDriftFound) {
+		return ErrorFollowUp{ExitCode: configDriftFoundExitCode}, true
+	} else if errors.Is(err, ErrInterrupted) {
+		return ErrorFollowUp{ExitCode: interruptedExitCode}, true
 	}
 
 	return zero, false
@@ -1,16 +1,14 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"runtime"
-	"sort"
-	"sync"
 
-	"github.com/dhth/ecsv/internal/aws"
-	"github.com/dhth/ecsv/internal/changes"
+	"github.com/dhth/ecsv/internal/check"
 	"github.com/dhth/ecsv/internal/types"
 	"github.com/dhth/ecsv/internal/ui"
 	"github.com/google/go-github/v72/github"
@@ -20,129 +18,54 @@ var (
 	errUnsupportedPlatformForHTMLOpen = errors.New("opening HTML output is not supported on this platform")
 	errCouldntRunOpenCmd              = errors.New("couldn't run command for opening local web page")
 	ErrCouldntOpenHTMLOutput          = errors.New("couldn't open HTML output")
+	ErrPoliciesViolated               = errors.New("policies violated")
+	ErrInterrupted                    = errors.New("interrupted")
 )
 
+// process fetches versions for config and prints them. Fetches that haven't
+// started by the time ctx is done are skipped, and ErrInterrupted is returned.
 func process(
+	ctx context.Context,
 	config types.Config,
 	uiConfig ui.Config,
-	awsConfigs map[string]aws.Config,
 	ghClient *github.Client,
 	maxConcFetches int,
+	showTimings bool,
 ) error {
-	versionResults := make(map[string]map[string]types.VersionResult)
-	resultChannel := make(chan types.VersionResult)
-
-	semaphore := make(chan struct{}, maxConcFetches)
-	var wg sync.WaitGroup
-
-	for _, s := range config.Versions {
-		awsConfig := awsConfigs[s.AWSConfigKey()]
-		if versionResults[s.Key] == nil {
-			versionResults[s.Key] = make(map[string]types.VersionResult)
-		}
-		versionResults[s.Key][s.Env] = types.VersionResult{}
-
-		if awsConfig.Err != nil {
-			versionResults[s.Key][s.Env] = types.VersionResult{
-				SystemKey: s.Key,
-				Env:       s.Env,
-				Err:       awsConfig.Err,
-			}
-			continue
-		}
-
-		wg.Add(1)
-
-		go func(system types.VersionsConfig) {
-			defer wg.Done()
-			semaphore <- struct{}{}
-			defer func() {
-				<-semaphore
-			}()
-			resultChannel <- aws.FetchSystemVersion(system, awsConfig)
-		}(s)
+	opts := check.Options{
+		MaxConcurrentFetches: maxConcFetches,
 	}
 
-	go func() {
-		wg.Wait()
-		close(resultChannel)
-	}()
-
-	for r := range resultChannel {
-		versionResults[r.SystemKey][r.Env] = r
+	// changes are only shown in the HTML output
+	if uiConfig.OutputFmt == types.HTMLFmt {
+		opts.GitHubClient = ghClient
 	}
 
-	changesResultChan := make(chan types.ChangesResult)
-
-	//nolint:prealloc
-	var changesResults []types.ChangesResult
-
-	if uiConfig.OutputFmt == types.HTMLFmt && len(config.Changes) > 0 {
-		chSemaphore := make(chan struct{}, maxConcFetches)
-		var changesWg sync.WaitGroup
-
-		for _, changesConfig := range config.Changes {
-			vrm, ok := versionResults[changesConfig.SystemKey]
-
-			// TODO: handle these conditions related to inconsistent state
-			if !ok {
-				continue
-			}
-
-			vrBase, ok := vrm[changesConfig.Base]
-			if !ok {
-				continue
-			}
-
-			if vrBase.Err != nil {
-				continue
-			}
-
-			vrHead, ok := vrm[changesConfig.Head]
-			if !ok {
-				continue
-			}
-
-			if vrHead.Err != nil {
-				continue
-			}
-
-			if vrBase.Version == vrHead.Version {
-				continue
-			}
-
-			changesWg.Add(1)
-			go func(baseRef, headRef string) {
-				defer changesWg.Done()
-				chSemaphore <- struct{}{}
-				defer func() {
-					<-chSemaphore
-				}()
-				changesResultChan <- changes.FetchChanges(
-					ghClient,
-					changesConfig,
-					baseRef,
-					headRef)
-			}(vrBase.Version,
-				vrHead.Version,
-			)
-		}
+	var liveView *ui.LiveView
+	if uiConfig.Live {
+		liveView = ui.NewLiveView(uiConfig, os.Stdout, config.Versions)
+		liveView.Start()
+		opts.OnResult = liveView.Update
+	}
 
-		go func() {
-			changesWg.Wait()
-			close(changesResultChan)
-		}()
+	result := check.Run(ctx, config, uiConfig.EnvSequence, opts)
 
-		for r := range changesResultChan {
-			changesResults = append(changesResults, r)
-		}
+	// the live view hides the cursor, so it needs to be finished (which
+	// restores it) even if the check is interrupted
+	if liveView != nil {
+		liveView.Finish()
+	}
 
-		sort.Slice(changesResults, func(i, j int) bool {
-			return changesResults[i].Config.SystemKey < changesResults[j].Config.SystemKey
-		})
+	if ctx.Err() != nil {
+		return ErrInterrupted
 	}
 
-	output, err := ui.GetOutput(uiConfig, versionResults, changesResults)
+	output, err := ui.GetOutput(uiConfig, ui.Report{
+		Versions:   result.Versions,
+		Drift:      result.Drift,
+		Changes:    result.Changes,
+		Violations: result.Violations,
+	})
 	if err != nil {
 		return err
 	}
@@ -156,6 +79,14 @@ func process(
 		fmt.Print(output)
 	}
 
+	if showTimings {
+		fmt.Fprint(os.Stderr, "\n"+ui.GetTimingsOutput(result.Timings))
+	}
+
+	if len(result.Violations) > 0 {
+		return fmt.Errorf("%w: %d violation(s) found", ErrPoliciesViolated, len(result.Violations))
+	}
+
 	return nil
 }
 
@@ -1,15 +1,20 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
+	"syscall"
 
-	"github.com/dhth/ecsv/internal/aws"
 	"github.com/dhth/ecsv/internal/changes"
+	"github.com/dhth/ecsv/internal/check"
+	"github.com/dhth/ecsv/internal/logging"
 	"github.com/dhth/ecsv/internal/types"
 	"github.com/dhth/ecsv/internal/ui"
 	"github.com/dhth/ecsv/internal/utils"
@@ -20,20 +25,23 @@ import (
 const configFileName = "ecsv/ecsv.yml"
 
 var (
-	errConfigFileNotYAML         = errors.New("config file needs to be a YAML file")
-	errCouldntGetUserHomeDir     = errors.New("couldn't get your home directory")
-	errCouldntGetUserConfigDir   = errors.New("couldn't get your config directory")
-	errConfigFileExtIncorrect    = errors.New("config file must be a YAML file")
-	errConfigFileDoesntExist     = errors.New("config file does not exist")
-	errCouldntReadConfigFile     = errors.New("couldn't read config file")
-	errCouldntParseConfigFile    = errors.New("couldn't parse config file")
-	errTemplateFileDoesntExit    = errors.New("template file doesn't exist")
-	errCouldntReadTemplateFile   = errors.New("couldn't read template file")
-	errIncorrectFormatProvided   = errors.New("incorrect value for format provided")
-	errNoSystemsFound            = errors.New("no systems found")
-	errIncorrectStyleProvided    = errors.New("incorrect style provided")
-	errIncorrectKeyRegexProvided = errors.New("incorrect key regex provided")
-	errGithubAuthNotConfigured   = errors.New("couldn't set up a GitHub client")
+	errConfigFileNotYAML            = errors.New("config file needs to be a YAML file")
+	errCouldntGetUserHomeDir        = errors.New("couldn't get your home directory")
+	errCouldntGetUserConfigDir      = errors.New("couldn't get your config directory")
+	errConfigFileDoesntExist        = errors.New("config file does not exist")
+	errCouldntReadConfigFile        = errors.New("couldn't read config file")
+	errCouldntParseConfigFile       = errors.New("couldn't parse config file")
+	errTemplateFileDoesntExit       = errors.New("template file doesn't exist")
+	errCouldntReadTemplateFile      = errors.New("couldn't read template file")
+	errIncorrectFormatProvided      = errors.New("incorrect value for format provided")
+	errNoSystemsFound               = errors.New("no systems found")
+	errIncorrectStyleProvided       = errors.New("incorrect style provided")
+	errIncorrectKeyRegexProvided    = errors.New("incorrect key regex provided")
+	errIncorrectEnvRegexProvided    = errors.New("incorrect env regex provided")
+	errIncorrectIgnoreRegexProvided = errors.New("incorrect ignore regex provided")
+	errGithubAuthNotConfigured      = errors.New("couldn't set up a GitHub client")
+	errCouldntGenerateSchema        = errors.New("couldn't generate JSON schema")
+	errIncorrectLogFormatProvided   = errors.New("incorrect log format provided")
 )
 
 func Execute() error {
@@ -48,10 +56,14 @@ func Execute() error {
 func NewRootCommand() (*cobra.Command, error) {
 	var (
 		configPath       string
-		configPathFull   string
-		configBytes      []byte
+		configFiles      []types.ConfigDocument
 		homeDir          string
 		keyFilter        string
+		keyExclude       string
+		envFilter        string
+		envExclude       string
+		tags             []string
+		groups           []string
 		format           string
 		htmlTemplateFile string
 		htmlTitle        string
@@ -59,7 +71,16 @@ func NewRootCommand() (*cobra.Command, error) {
 		htmlOpen         bool
 		tableStyleStr    string
 		showRegisteredAt bool
+		showTaskDefs     bool
 		debug            bool
+		live             bool
+		showTimings      bool
+		auditIgnore      string
+		discoverProfiles []string
+		discoverRegion   string
+		discoverOutput   string
+		verbose          bool
+		logFormat        string
 	)
 
 	rootCmd := &cobra.Command{
@@ -67,25 +88,29 @@ func NewRootCommand() (*cobra.Command, error) {
 		Short:        "ecsv lets you quickly check the code versions of services running on ECS across various environments",
 		SilenceUsage: true,
 		PersistentPreRunE: func(_ *cobra.Command, _ []string) error {
-			if !strings.HasSuffix(configPath, ".yml") && !strings.HasSuffix(configPath, ".yaml") {
-				return errConfigFileNotYAML
+			if !slices.Contains(logFormats, logFormat) {
+				return fmt.Errorf("%w: possible values: %q", errIncorrectLogFormatProvided, logFormats)
 			}
 
-			var err error
-			configPathFull = utils.ExpandTilde(configPath, homeDir)
-			configBytes, err = os.ReadFile(configPathFull)
-			if err != nil {
-				return fmt.Errorf("%w: %w", errCouldntReadConfigFile, err)
+			if verbose {
+				logging.Enable(os.Stderr, logFormat == "json")
 			}
 
 			return nil
 		},
 	}
 
+	loadConfig := func(_ *cobra.Command, _ []string) error {
+		var err error
+		configFiles, err = loadConfigFiles(utils.ExpandTilde(configPath, homeDir), homeDir)
+		return err
+	}
+
 	checkCmd := &cobra.Command{
 		Use:          "check",
 		Short:        "gather code versions and show report",
 		SilenceUsage: true,
+		PreRunE:      loadConfig,
 		RunE: func(_ *cobra.Command, _ []string) error {
 			var outFormat types.OutputFmt
 			if format != "" {
@@ -96,6 +121,8 @@ func NewRootCommand() (*cobra.Command, error) {
 					outFormat = types.TabularFmt
 				case "html":
 					outFormat = types.HTMLFmt
+				case "junit":
+					outFormat = types.JUnitFmt
 				default:
 					return fmt.Errorf("%w; possible values: %v", errIncorrectFormatProvided, types.OutputFormats())
 				}
@@ -114,25 +141,33 @@ func NewRootCommand() (*cobra.Command, error) {
 				htmlTemplate = string(templateFileContents)
 			}
 
-			var keyFilterRegex *regexp.Regexp
+			var filters types.Filters
 			var err error
-			if keyFilter != "" {
-				keyFilterRegex, err = regexp.Compile(keyFilter)
-				if err != nil {
-					return fmt.Errorf("%w: %s", errIncorrectKeyRegexProvided, err.Error())
-				}
+			filters.KeyFilter, err = compileFilter(keyFilter, errIncorrectKeyRegexProvided)
+			if err != nil {
+				return err
 			}
-
-			if filepath.Ext(configPathFull) != ".yml" && filepath.Ext(configPathFull) != ".yaml" {
-				return errConfigFileExtIncorrect
+			filters.KeyExclude, err = compileFilter(keyExclude, errIncorrectKeyRegexProvided)
+			if err != nil {
+				return err
+			}
+			filters.EnvFilter, err = compileFilter(envFilter, errIncorrectEnvRegexProvided)
+			if err != nil {
+				return err
 			}
+			filters.EnvExclude, err = compileFilter(envExclude, errIncorrectEnvRegexProvided)
+			if err != nil {
+				return err
+			}
+			filters.Tags = tags
+			filters.Groups = groups
 
-			_, err = os.Stat(configPathFull)
-			if os.IsNotExist(err) {
-				return fmt.Errorf("%w: %s", errConfigFileDoesntExist, err.Error())
+			ecsvConfig, err := mergeConfigFiles(configFiles)
+			if err != nil {
+				return fmt.Errorf("%w: %w", errCouldntParseConfigFile, err)
 			}
 
-			envSequence, config, err := readConfig(configBytes, keyFilterRegex)
+			envSequence, config, err := check.ReadConfig(ecsvConfig, filters)
 			if err != nil {
 				return fmt.Errorf("%w: %s", errCouldntParseConfigFile, err.Error())
 			}
@@ -154,33 +189,19 @@ func NewRootCommand() (*cobra.Command, error) {
 				}
 			}
 
-			awsConfigs := make(map[string]aws.Config)
-
-			seenSystems := make(map[string]bool)
-			var systemKeys []string
-			seenConfigs := make(map[string]bool)
-
-			for _, system := range config.Versions {
-				if !seenSystems[system.Key] {
-					systemKeys = append(systemKeys, system.Key)
-					seenSystems[system.Key] = true
-				}
+			systemKeys := check.SystemKeys(config)
 
-				if !seenConfigs[system.AWSConfigKey()] {
-					cfg, err := aws.GetConfig(system)
-					awsConfigs[system.AWSConfigKey()] = aws.Config{
-						Config: cfg,
-						Err:    err,
-					}
-					seenSystems[system.Key] = true
-				}
-			}
+			// logs written via --verbose would get mixed up with the live view
+			showLive := live && !verbose && outFormat == types.DefaultFmt && utils.IsTerminal(os.Stdout)
 
 			uiConfig := ui.Config{
-				EnvSequence:      envSequence,
-				SystemKeys:       systemKeys,
-				OutputFmt:        outFormat,
-				ShowRegisteredAt: showRegisteredAt,
+				EnvSequence:         envSequence,
+				SystemKeys:          systemKeys,
+				Groups:              groupSystems(systemKeys, config.Metadata),
+				OutputFmt:           outFormat,
+				ShowRegisteredAt:    showRegisteredAt,
+				ShowTaskDefinitions: showTaskDefs,
+				Live:                showLive,
 			}
 			switch outFormat {
 			case types.HTMLFmt:
@@ -208,7 +229,153 @@ func NewRootCommand() (*cobra.Command, error) {
 				return nil
 			}
 
-			return process(config, uiConfig, awsConfigs, ghClient, maxConcFetches)
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			return process(ctx, config, uiConfig, ghClient, maxConcFetches, showTimings)
+		},
+	}
+
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "work with ecsv's config",
+	}
+
+	configValidateCmd := &cobra.Command{
+		Use:          "validate",
+		Short:        "validate config, and report all problems along with their location",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		PreRunE:      loadConfig,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return validateConfig(configFiles)
+		},
+	}
+
+	configSchemaCmd := &cobra.Command{
+		Use:          "schema",
+		Short:        "print the JSON Schema for ecsv's config file",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			schema, err := types.ConfigSchema()
+			if err != nil {
+				return fmt.Errorf("%w: %w", errCouldntGenerateSchema, err)
+			}
+
+			fmt.Print(string(schema))
+			return nil
+		},
+	}
+
+	auditCmd := &cobra.Command{
+		Use:   "audit",
+		Short: "find services missing from the config, and config entries whose service doesn't exist",
+		Long: `audit lists the ECS services in the clusters referred to by the config, and
+reports services that aren't covered by any system, as well as config entries
+whose service no longer exists. It exits with a non-zero code if it finds
+either.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		PreRunE:      loadConfig,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			var filters types.Filters
+			var err error
+			filters.KeyFilter, err = compileFilter(keyFilter, errIncorrectKeyRegexProvided)
+			if err != nil {
+				return err
+			}
+			filters.EnvFilter, err = compileFilter(envFilter, errIncorrectEnvRegexProvided)
+			if err != nil {
+				return err
+			}
+			ignore, err := compileFilter(auditIgnore, errIncorrectIgnoreRegexProvided)
+			if err != nil {
+				return err
+			}
+
+			ecsvConfig, err := mergeConfigFiles(configFiles)
+			if err != nil {
+				return fmt.Errorf("%w: %w", errCouldntParseConfigFile, err)
+			}
+
+			_, fullConfig, err := check.ReadConfig(ecsvConfig, types.Filters{})
+			if err != nil {
+				return fmt.Errorf("%w: %s", errCouldntParseConfigFile, err.Error())
+			}
+
+			_, config, err := check.ReadConfig(ecsvConfig, filters)
+			if err != nil {
+				return fmt.Errorf("%w: %s", errCouldntParseConfigFile, err.Error())
+			}
+
+			if len(config.Versions) == 0 {
+				return fmt.Errorf("%w", errNoSystemsFound)
+			}
+
+			maxConcFetches, err := getMaxConcFetches()
+			if err != nil {
+				return err
+			}
+
+			return runAudit(config, fullConfig, ignore, maxConcFetches)
+		},
+	}
+
+	doctorCmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "check that the credentials used by the config work",
+		Long: `doctor verifies every distinct AWS config used by the config (resolving
+credentials, calling sts:GetCallerIdentity, and checking ecs:DescribeServices
+access), as well as GitHub auth if any system shows changes.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		PreRunE:      loadConfig,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			tableStyle, ok := types.GetStyle(tableStyleStr)
+			if !ok {
+				return fmt.Errorf("%w: potential values: %q", errIncorrectStyleProvided, types.TableStyleStrings())
+			}
+
+			ecsvConfig, err := mergeConfigFiles(configFiles)
+			if err != nil {
+				return fmt.Errorf("%w: %w", errCouldntParseConfigFile, err)
+			}
+
+			_, config, err := check.ReadConfig(ecsvConfig, types.Filters{})
+			if err != nil {
+				return fmt.Errorf("%w: %s", errCouldntParseConfigFile, err.Error())
+			}
+
+			maxConcFetches, err := getMaxConcFetches()
+			if err != nil {
+				return err
+			}
+
+			return runDoctor(config, tableStyle, maxConcFetches)
+		},
+	}
+
+	discoverCmd := &cobra.Command{
+		Use:   "discover",
+		Short: "discover ECS services and print a draft config for them",
+		Long: `discover lists the ECS services accessible via one or more AWS profiles,
+guesses system keys by stripping env names from service names, and prints a
+draft config mapping systems to envs.
+
+Profiles are provided as "[env=]profile[@region]", in the order envs are to
+appear in env-sequence.`,
+		Example: `  ecsv discover -p qa=acme-qa -p prod=acme-prod -r eu-central-1
+  ecsv discover -p qa@eu-central-1 -p prod@eu-west-1 -o ecsv.yml`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			maxConcFetches, err := getMaxConcFetches()
+			if err != nil {
+				return err
+			}
+
+			return runDiscover(discoverProfiles, discoverRegion, discoverOutput, maxConcFetches)
 		},
 	}
 
@@ -225,9 +392,16 @@ func NewRootCommand() (*cobra.Command, error) {
 
 	defaultConfigPath := filepath.Join(configDir, configFileName)
 
-	rootCmd.PersistentFlags().StringVarP(&configPath, "config-path", "c", defaultConfigPath, "location of ecsv's config file")
+	rootCmd.PersistentFlags().StringVarP(&configPath, "config-path", "c", defaultConfigPath, "location of ecsv's config file, or of a directory containing config files")
+	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "whether to log every call made to AWS and GitHub (to stderr)")
+	rootCmd.PersistentFlags().StringVar(&logFormat, "log-format", "text", fmt.Sprintf("format to use for logs enabled via --verbose [possible values: %s]", strings.Join(logFormats, ", ")))
 
 	checkCmd.Flags().StringVarP(&keyFilter, "key-filter", "k", "", "regex for filtering systems (by key)")
+	checkCmd.Flags().StringVar(&keyExclude, "key-exclude", "", "regex for excluding systems (by key)")
+	checkCmd.Flags().StringVarP(&envFilter, "env-filter", "e", "", "regex for filtering envs (eg. \"^(staging|prod)$\")")
+	checkCmd.Flags().StringVar(&envExclude, "env-exclude", "", "regex for excluding envs")
+	checkCmd.Flags().StringSliceVarP(&tags, "tags", "t", nil, "only check systems that have at least one of these tags")
+	checkCmd.Flags().StringSliceVarP(&groups, "groups", "g", nil, "only check systems that belong to one of these groups")
 	checkCmd.Flags().StringVarP(&format, "format", "f", "default", fmt.Sprintf("output format to use [possible values: %s]", strings.Join(types.OutputFormats(), ", ")))
 	checkCmd.Flags().StringVar(&htmlTemplateFile, "html-template-file", "", "path of the HTML template file to use")
 	checkCmd.Flags().StringVar(&htmlTitle, "html-title", "ecsv", "title to be used in the html output")
@@ -235,10 +409,42 @@ func NewRootCommand() (*cobra.Command, error) {
 	checkCmd.Flags().BoolVar(&htmlOpen, "html-open", true, "whether to write the html output to a temporary file and open it")
 	checkCmd.Flags().StringVar(&tableStyleStr, "table-style", types.ASCIIStyle.String(), fmt.Sprintf("style to use for tabular output [possible values: %s]", strings.Join(types.TableStyleStrings(), ", ")))
 	checkCmd.Flags().BoolVar(&showRegisteredAt, "show-registered-at", true, "whether to show the time when the task definition corresponding to a container was registered")
+	checkCmd.Flags().BoolVar(&showTaskDefs, "show-task-definitions", false, "whether to show the task definition (family:revision, image URI, CPU/memory, and ARN) behind each version fetched from ECS")
 	checkCmd.Flags().BoolVar(&debug, "debug", false, "whether to show debug information without running the checks")
+	checkCmd.Flags().BoolVar(&showTimings, "show-timings", false, "whether to print a breakdown of how long fetches took per AWS config and GitHub host (to stderr)")
+	checkCmd.Flags().BoolVar(&live, "live", true, "whether to show results as they're fetched (only applies to the default format, when writing to a terminal, without --verbose)")
+
+	auditCmd.Flags().StringVarP(&keyFilter, "key-filter", "k", "", "regex for filtering systems (by key)")
+	auditCmd.Flags().StringVarP(&envFilter, "env-filter", "e", "", "regex for filtering envs (eg. \"^(staging|prod)$\")")
+	auditCmd.Flags().StringVar(&auditIgnore, "ignore", "", "regex for services that are not expected to be covered by the config")
+
+	doctorCmd.Flags().StringVar(&tableStyleStr, "table-style", types.ASCIIStyle.String(), fmt.Sprintf("style to use for the output [possible values: %s]", strings.Join(types.TableStyleStrings(), ", ")))
+
+	discoverCmd.Flags().StringArrayVarP(&discoverProfiles, "profile", "p", nil, "AWS profile to discover services for, as \"[env=]profile[@region]\" (can be repeated)")
+	discoverCmd.Flags().StringVarP(&discoverRegion, "region", "r", os.Getenv("AWS_REGION"), "AWS region to use for profiles that don't specify one")
+	discoverCmd.Flags().StringVarP(&discoverOutput, "output", "o", "", "file to write the draft config to (printed to stdout if not provided)")
 
+	configCmd.AddCommand(configValidateCmd)
+	configCmd.AddCommand(configSchemaCmd)
 	rootCmd.AddCommand(checkCmd)
+	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(discoverCmd)
+	rootCmd.AddCommand(auditCmd)
+	rootCmd.AddCommand(doctorCmd)
 	rootCmd.CompletionOptions.DisableDefaultCmd = true
 
 	return rootCmd, nil
 }
+
+func compileFilter(pattern string, errToWrap error) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errToWrap, err.Error())
+	}
+
+	return re, nil
+}
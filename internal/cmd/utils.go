@@ -5,14 +5,20 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+
+	"github.com/dhth/ecsv/internal/types"
+	"github.com/dhth/ecsv/internal/ui"
 )
 
 const (
+	unassignedGroupName                = "unassigned"
 	maxConcurrentFetchesDefault        = 10
 	maxConcurrentFetchesUpperThreshold = 50
 	maxConcurrentFetchesEnvVar         = "ECSV_MAX_CONCURRENT_FETCHES"
 )
 
+var logFormats = []string{"text", "json"}
+
 var errMaxConcFetchesIsInvalid = errors.New("maximum concurrent fetches is invalid")
 
 func getMaxConcFetches() (int, error) {
@@ -33,3 +39,42 @@ func getMaxConcFetches() (int, error) {
 
 	return maxFetches, nil
 }
+
+// groupSystems groups systems by their group, or by the team that owns them
+// for systems without one, in the order groups first appear in. Systems with
+// neither are grouped together at the end. If no system has a group or a
+// team, no groups are returned.
+func groupSystems(systemKeys []string, metadata map[string]types.SystemMetadata) []ui.SystemGroup {
+	var groups []ui.SystemGroup
+	groupIndex := make(map[string]int)
+	var unassigned []string
+
+	for _, key := range systemKeys {
+		name := metadata[key].Group
+		if name == "" {
+			name = metadata[key].Team
+		}
+		if name == "" {
+			unassigned = append(unassigned, key)
+			continue
+		}
+
+		index, ok := groupIndex[name]
+		if !ok {
+			index = len(groups)
+			groupIndex[name] = index
+			groups = append(groups, ui.SystemGroup{Name: name})
+		}
+		groups[index].SystemKeys = append(groups[index].SystemKeys, key)
+	}
+
+	if len(groups) == 0 {
+		return nil
+	}
+
+	if len(unassigned) > 0 {
+		groups = append(groups, ui.SystemGroup{Name: unassignedGroupName, SystemKeys: unassigned})
+	}
+
+	return groups
+}
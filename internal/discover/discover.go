@@ -0,0 +1,203 @@
+package discover
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/dhth/ecsv/internal/aws"
+	"gopkg.in/yaml.v3"
+)
+
+// envAliases holds the other names an env commonly goes by in resource names.
+var envAliases = map[string][]string{
+	"dev":         {"development"},
+	"development": {"dev"},
+	"qa":          {"test"},
+	"test":        {"qa"},
+	"staging":     {"stage", "stg"},
+	"stage":       {"staging", "stg"},
+	"stg":         {"staging", "stage"},
+	"prod":        {"production", "prd"},
+	"production":  {"prod", "prd"},
+	"prd":         {"prod", "production"},
+}
+
+// Target is an AWS account/region whose services are to be treated as
+// belonging to an env.
+type Target struct {
+	Env     string
+	Profile string
+	Region  string
+}
+
+// EnvServices is the result of discovering services for a Target.
+type EnvServices struct {
+	Target   Target
+	Services []aws.DiscoveredService
+}
+
+// GuessKey guesses a system's key from the name of its service in env, by
+// stripping the env's name (or a common alias of it) from its start and end,
+// eg. "payments-api-qa" -> "payments-api".
+func GuessKey(service, env string) string {
+	names := append([]string{strings.ToLower(env)}, envAliases[strings.ToLower(env)]...)
+
+	tokens := strings.FieldsFunc(service, func(r rune) bool {
+		return r == '-' || r == '_' || r == '.'
+	})
+	seps := separators(service)
+
+	start, end := 0, len(tokens)
+	for end-start > 1 && slices.Contains(names, strings.ToLower(tokens[end-1])) {
+		end--
+	}
+	for end-start > 1 && slices.Contains(names, strings.ToLower(tokens[start])) {
+		start++
+	}
+
+	var b strings.Builder
+	for i := start; i < end; i++ {
+		if i > start {
+			b.WriteByte(seps[i-1])
+		}
+		b.WriteString(tokens[i])
+	}
+
+	return b.String()
+}
+
+// separators returns the characters between the tokens of a name.
+func separators(name string) []byte {
+	var seps []byte
+	prevWasSep := true
+	for i := range len(name) {
+		isSep := name[i] == '-' || name[i] == '_' || name[i] == '.'
+		if isSep && !prevWasSep {
+			seps = append(seps, name[i])
+		}
+		prevWasSep = isSep
+	}
+
+	return seps
+}
+
+type draftEnvDefaults struct {
+	AWSConfigSource string `yaml:"aws-config-source"`
+	AWSRegion       string `yaml:"aws-region"`
+}
+
+type draftEnv struct {
+	Name          string    `yaml:"name"`
+	Cluster       string    `yaml:"cluster"`
+	Service       string    `yaml:"service"`
+	ContainerName yaml.Node `yaml:"container-name"`
+}
+
+type draftSystem struct {
+	Key  string     `yaml:"key"`
+	Envs []draftEnv `yaml:"envs"`
+}
+
+type draftConfig struct {
+	EnvSequence []string                    `yaml:"env-sequence,flow"`
+	Envs        map[string]draftEnvDefaults `yaml:"envs"`
+	Systems     []draftSystem               `yaml:"systems"`
+}
+
+// Draft builds a config file from discovered services; envs appear in
+// env-sequence in the order of results. Where the container to use can't be
+// determined with certainty, the candidates are mentioned in a comment.
+func Draft(results []EnvServices) ([]byte, error) {
+	config := draftConfig{
+		Envs: make(map[string]draftEnvDefaults),
+	}
+	systems := make(map[string]*draftSystem)
+
+	for _, result := range results {
+		env := result.Target.Env
+		config.EnvSequence = append(config.EnvSequence, env)
+		config.Envs[env] = draftEnvDefaults{
+			AWSConfigSource: fmt.Sprintf("profile:::%s", result.Target.Profile),
+			AWSRegion:       result.Target.Region,
+		}
+
+		services := slices.Clone(result.Services)
+		sort.Slice(services, func(i, j int) bool {
+			if services[i].Service != services[j].Service {
+				return services[i].Service < services[j].Service
+			}
+			return services[i].Cluster < services[j].Cluster
+		})
+
+		for _, svc := range services {
+			key := GuessKey(svc.Service, env)
+			system, ok := systems[key]
+			if ok && slices.ContainsFunc(system.Envs, func(e draftEnv) bool { return e.Name == env }) {
+				// another service in the same env maps to the same key, eg. when
+				// it's deployed to more than one cluster
+				key = fmt.Sprintf("%s-%s", svc.Service, svc.Cluster)
+				system, ok = systems[key]
+			}
+			if !ok {
+				system = &draftSystem{Key: key}
+				systems[key] = system
+			}
+
+			system.Envs = append(system.Envs, draftEnv{
+				Name:          env,
+				Cluster:       svc.Cluster,
+				Service:       svc.Service,
+				ContainerName: containerNameNode(svc.Containers, key),
+			})
+		}
+	}
+
+	keys := make([]string, 0, len(systems))
+	for key := range systems {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		config.Systems = append(config.Systems, *systems[key])
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("# generated by \"ecsv discover\"; review system keys and container names before use\n")
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(config); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// containerNameNode picks the container whose image tag is most likely the
+// service's version: the only container, or the one named after the system.
+func containerNameNode(containers []string, key string) yaml.Node {
+	node := yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str"}
+
+	switch {
+	case len(containers) == 0:
+		node.LineComment = "TODO: no containers found"
+	case len(containers) == 1:
+		node.Value = containers[0]
+	default:
+		idx := slices.IndexFunc(containers, func(c string) bool {
+			return strings.Contains(c, key)
+		})
+		if idx == -1 {
+			idx = 0
+		}
+		node.Value = containers[idx]
+		node.LineComment = fmt.Sprintf("TODO: verify; candidates: %s", strings.Join(containers, ", "))
+	}
+
+	return node
+}
@@ -0,0 +1,87 @@
+package discover
+
+import (
+	"testing"
+
+	"github.com/dhth/ecsv/internal/aws"
+)
+
+func TestGuessKey(t *testing.T) {
+	testCases := []struct {
+		service  string
+		env      string
+		expected string
+	}{
+		{service: "payments-api-qa", env: "qa", expected: "payments-api"},
+		{service: "qa-payments-api", env: "qa", expected: "payments-api"},
+		{service: "payments_api_production", env: "prod", expected: "payments_api"},
+		{service: "payments-api-stg", env: "staging", expected: "payments-api"},
+		{service: "payments-api", env: "qa", expected: "payments-api"},
+		{service: "qa", env: "qa", expected: "qa"},
+		{service: "quality-api", env: "qa", expected: "quality-api"},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.service, func(t *testing.T) {
+			got := GuessKey(tt.service, tt.env)
+
+			if got != tt.expected {
+				t.Errorf("got: %q, expected: %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDraft(t *testing.T) {
+	results := []EnvServices{
+		{
+			Target: Target{Env: "qa", Profile: "qa-account", Region: "eu-central-1"},
+			Services: []aws.DiscoveredService{
+				{Cluster: "main-qa", Service: "payments-api-qa", Containers: []string{"payments-api", "datadog-agent"}},
+				{Cluster: "main-qa", Service: "auth-qa", Containers: []string{"auth"}},
+			},
+		},
+		{
+			Target: Target{Env: "prod", Profile: "prod-account", Region: "eu-west-1"},
+			Services: []aws.DiscoveredService{
+				{Cluster: "main-prod", Service: "payments-api-prod", Containers: []string{"payments-api"}},
+			},
+		},
+	}
+
+	got, err := Draft(results)
+	if err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+
+	expected := `# generated by "ecsv discover"; review system keys and container names before use
+env-sequence: [qa, prod]
+envs:
+  prod:
+    aws-config-source: profile:::prod-account
+    aws-region: eu-west-1
+  qa:
+    aws-config-source: profile:::qa-account
+    aws-region: eu-central-1
+systems:
+  - key: auth
+    envs:
+      - name: qa
+        cluster: main-qa
+        service: auth-qa
+        container-name: auth
+  - key: payments-api
+    envs:
+      - name: qa
+        cluster: main-qa
+        service: payments-api-qa
+        container-name: payments-api # TODO: verify; candidates: payments-api, datadog-agent
+      - name: prod
+        cluster: main-prod
+        service: payments-api-prod
+        container-name: payments-api
+`
+	if string(got) != expected {
+		t.Errorf("got:\n%s\nexpected:\n%s", got, expected)
+	}
+}
@@ -0,0 +1,162 @@
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/dhth/ecsv/internal/aws"
+	"github.com/dhth/ecsv/internal/changes"
+	"github.com/dhth/ecsv/internal/types"
+)
+
+const checkTimeout = 10 * time.Second
+
+// Check is the result of verifying that a set of credentials (an AWS config,
+// or GitHub auth) works.
+type Check struct {
+	Credentials string
+	UsedBy      []string
+	Identity    string
+	// Access describes what access was verified, eg. "ecs:DescribeServices on
+	// cluster/service"
+	Access string
+	Err    error
+	Hint   string
+}
+
+func (c Check) OK() bool {
+	return c.Err == nil
+}
+
+// CheckAWS verifies every distinct AWS config used by config entries: that
+// credentials can be resolved, the identity they belong to, and that they
+// allow reading one of the services (or functions, parameters, or stacks)
+// they're used for.
+func CheckAWS(versions []types.VersionsConfig, maxConcFetches int) []Check {
+	var keys []string
+	entries := make(map[string][]types.VersionsConfig)
+	for _, v := range versions {
+		if !v.Source.IsAWS() {
+			continue
+		}
+
+		key := v.AWSConfigKey()
+		if _, ok := entries[key]; !ok {
+			keys = append(keys, key)
+		}
+		entries[key] = append(entries[key], v)
+	}
+
+	checks := make([]Check, len(keys))
+	semaphore := make(chan struct{}, maxConcFetches)
+	var wg sync.WaitGroup
+
+	for i, key := range keys {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() {
+				<-semaphore
+			}()
+
+			checks[i] = checkAWSConfig(entries[key])
+		}()
+	}
+	wg.Wait()
+
+	return checks
+}
+
+func checkAWSConfig(entries []types.VersionsConfig) Check {
+	first := entries[0]
+	check := Check{Credentials: first.AWSConfigDescription()}
+	for _, e := range entries {
+		if !slices.Contains(check.UsedBy, e.Env) {
+			check.UsedBy = append(check.UsedBy, e.Env)
+		}
+	}
+
+	var profile string
+	if first.AWSConfigSourceType == types.SharedCfgProfileType {
+		profile = first.AWSConfigSource
+	}
+
+	fail := func(err error) Check {
+		check.Err = err
+		check.Hint = aws.ErrorHint(err, profile)
+		return check
+	}
+
+	cfg, err := aws.GetConfig(first)
+	if err != nil {
+		return fail(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), checkTimeout)
+	defer cancel()
+
+	check.Identity, err = aws.GetCallerIdentity(ctx, cfg)
+	if err != nil {
+		return fail(err)
+	}
+
+	// prefer verifying access to ECS, since most entries use it
+	target := first
+	if i := slices.IndexFunc(entries, func(e types.VersionsConfig) bool {
+		return e.Source == types.ECSSource
+	}); i >= 0 {
+		target = entries[i]
+	}
+
+	switch target.Source {
+	case types.LambdaSource:
+		check.Access = fmt.Sprintf("lambda:GetFunction on %s", target.FunctionName)
+		err = aws.CheckGetFunction(ctx, cfg, target.FunctionName)
+	case types.SSMSource:
+		check.Access = fmt.Sprintf("ssm:GetParameter on %s", target.ParameterName)
+		err = aws.CheckGetParameter(ctx, cfg, target.ParameterName)
+	case types.CFNSource:
+		check.Access = fmt.Sprintf("cloudformation:DescribeStacks on %s", target.StackName)
+		err = aws.CheckDescribeStacks(ctx, cfg, target.StackName)
+	default:
+		check.Access = fmt.Sprintf("ecs:DescribeServices on %s/%s", target.ClusterName, target.ServiceName)
+		err = aws.CheckDescribeServices(ctx, cfg, target.ClusterName, target.ServiceName)
+	}
+	if err != nil {
+		return fail(err)
+	}
+
+	return check
+}
+
+// CheckGitHub verifies that a GitHub client can be set up, and that it's
+// authenticated.
+func CheckGitHub() Check {
+	check := Check{
+		Credentials: "GitHub",
+		UsedBy:      []string{"changes"},
+		Access:      "GET /user",
+	}
+
+	client, err := changes.GetGHClient()
+	if err != nil {
+		check.Err = err
+		check.Hint = "set GH_TOKEN, or log in via \"gh auth login\""
+		return check
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), checkTimeout)
+	defer cancel()
+
+	check.Identity, err = changes.GetAuthenticatedUser(ctx, client)
+	if err != nil {
+		check.Err = err
+		check.Hint = "the GitHub token is invalid or has expired"
+	}
+
+	return check
+}
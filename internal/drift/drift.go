@@ -0,0 +1,213 @@
+package drift
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/dhth/ecsv/internal/types"
+	"github.com/dhth/ecsv/internal/utils"
+)
+
+type Direction uint
+
+const (
+	InSync Direction = iota
+	UpstreamAhead
+	DownstreamAhead
+	Incomparable
+)
+
+var semverLevels = []string{"major", "minor", "patch"}
+
+// Drift describes how the versions running in two consecutive envs (as per
+// env-sequence) differ from each other.
+type Drift struct {
+	Upstream          string
+	Downstream        string
+	UpstreamVersion   string
+	DownstreamVersion string
+	Direction         Direction
+	// Level is the index of the first version component that differs; it equals
+	// the number of components when only the pre-release differs.
+	Level    int
+	Distance int
+	// Lag is how long the upstream env has been ahead of the downstream env,
+	// based on when the upstream env's task definition was registered. It's
+	// only set when HasLag is true.
+	Lag      time.Duration
+	HasLag   bool
+	kind     Kind
+	numComps int
+}
+
+// IsAnomaly reports whether a downstream env is running a newer version than
+// the env preceding it in env-sequence.
+func (d Drift) IsAnomaly() bool {
+	return d.Direction == DownstreamAhead
+}
+
+func (d Drift) String() string {
+	switch d.Direction {
+	case InSync:
+		return fmt.Sprintf("%s and %s are in sync", d.Upstream, d.Downstream)
+	case UpstreamAhead:
+		if d.HasLag {
+			return fmt.Sprintf("%s has been ahead of %s%s for %s", d.Upstream, d.Downstream, d.magnitude(), utils.HumanizeDuration(d.Lag))
+		}
+		return fmt.Sprintf("%s is ahead of %s%s", d.Upstream, d.Downstream, d.magnitude())
+	case DownstreamAhead:
+		return fmt.Sprintf("%s is ahead of %s%s", d.Downstream, d.Upstream, d.magnitude())
+	default:
+		return fmt.Sprintf("%s (%s) and %s (%s) can't be compared", d.Upstream, d.UpstreamVersion, d.Downstream, d.DownstreamVersion)
+	}
+}
+
+func (d Drift) magnitude() string {
+	if d.Level >= d.numComps {
+		return " (pre-release)"
+	}
+
+	if d.kind == SemverKind {
+		unit := "version"
+		if d.Distance > 1 {
+			unit = "versions"
+		}
+		return fmt.Sprintf(" by %d %s %s", d.Distance, semverLevels[d.Level], unit)
+	}
+
+	if d.numComps == 1 {
+		return fmt.Sprintf(" by %d", d.Distance)
+	}
+
+	return fmt.Sprintf(" by %d (component %d)", d.Distance, d.Level+1)
+}
+
+// Analysis holds the drift between each pair of consecutive envs that a
+// version was found for.
+type Analysis struct {
+	Drifts []Drift
+}
+
+func (a Analysis) OutOfSync() []Drift {
+	var drifts []Drift
+	for _, d := range a.Drifts {
+		if d.Direction != InSync {
+			drifts = append(drifts, d)
+		}
+	}
+
+	return drifts
+}
+
+func (a Analysis) Anomalies() []Drift {
+	var drifts []Drift
+	for _, d := range a.Drifts {
+		if d.IsAnomaly() {
+			drifts = append(drifts, d)
+		}
+	}
+
+	return drifts
+}
+
+func (a Analysis) HasAnomaly() bool {
+	return len(a.Anomalies()) > 0
+}
+
+// Summary returns a single line description of all out of sync envs.
+func (a Analysis) Summary() string {
+	outOfSync := a.OutOfSync()
+	parts := make([]string, len(outOfSync))
+	for i, d := range outOfSync {
+		if d.IsAnomaly() {
+			parts[i] = fmt.Sprintf("anomaly: %s", d.String())
+		} else {
+			parts[i] = d.String()
+		}
+	}
+
+	return strings.Join(parts, "; ")
+}
+
+// Analyze compares the versions of a system across consecutive envs in
+// envSequence. Envs for which a version couldn't be fetched are skipped.
+func Analyze(envSequence []string, results map[string]types.VersionResult, fallback *regexp.Regexp, now time.Time) Analysis {
+	var analysis Analysis
+	var previous *types.VersionResult
+
+	for _, env := range envSequence {
+		r, ok := results[env]
+		if !ok || r.Err != nil || !r.Found || r.Version == "" {
+			continue
+		}
+
+		if previous != nil {
+			analysis.Drifts = append(analysis.Drifts, compare(*previous, r, fallback, now))
+		}
+		previous = &r
+	}
+
+	return analysis
+}
+
+func compare(upstream, downstream types.VersionResult, fallback *regexp.Regexp, now time.Time) Drift {
+	d := Drift{
+		Upstream:          upstream.Env,
+		Downstream:        downstream.Env,
+		UpstreamVersion:   upstream.Version,
+		DownstreamVersion: downstream.Version,
+	}
+
+	if upstream.Version == downstream.Version {
+		d.Direction = InSync
+		return d
+	}
+
+	uv, uOk := Parse(upstream.Version, fallback)
+	dv, dOk := Parse(downstream.Version, fallback)
+	if !uOk || !dOk {
+		d.Direction = Incomparable
+		return d
+	}
+
+	cmp, ok := Compare(uv, dv)
+	if !ok {
+		d.Direction = Incomparable
+		return d
+	}
+
+	d.kind = uv.Kind
+	d.numComps = len(uv.Components)
+	d.Level = d.numComps
+	for i := range uv.Components {
+		if uv.Components[i] != dv.Components[i] {
+			d.Level = i
+			d.Distance = abs(uv.Components[i] - dv.Components[i])
+			break
+		}
+	}
+
+	switch cmp {
+	case 1:
+		d.Direction = UpstreamAhead
+		if upstream.RegisteredAt != nil && now.After(*upstream.RegisteredAt) {
+			d.Lag = now.Sub(*upstream.RegisteredAt)
+			d.HasLag = true
+		}
+	case -1:
+		d.Direction = DownstreamAhead
+	default:
+		d.Direction = InSync
+	}
+
+	return d
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
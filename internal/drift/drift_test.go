@@ -0,0 +1,156 @@
+package drift
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/dhth/ecsv/internal/types"
+)
+
+func TestCompare(t *testing.T) {
+	buildPattern := regexp.MustCompile(`^build-(\d+)$`)
+	datePattern := regexp.MustCompile(`^(\d{4})-(\d{2})-(\d{2})$`)
+
+	testCases := []struct {
+		name       string
+		a          string
+		b          string
+		pattern    *regexp.Regexp
+		expected   int
+		canCompare bool
+	}{
+		{name: "equal semver", a: "v1.2.3", b: "1.2.3", expected: 0, canCompare: true},
+		{name: "newer major", a: "v2.0.0", b: "v1.9.9", expected: 1, canCompare: true},
+		{name: "older patch", a: "v1.2.3", b: "v1.2.4", expected: -1, canCompare: true},
+		{name: "release newer than pre-release", a: "v1.2.3", b: "v1.2.3-rc.1", expected: 1, canCompare: true},
+		{name: "numeric pre-release identifiers", a: "v1.2.3-rc.2", b: "v1.2.3-rc.10", expected: -1, canCompare: true},
+		{name: "build metadata is ignored", a: "v1.2.3+abc", b: "v1.2.3+def", expected: 0, canCompare: true},
+		{name: "fallback pattern", a: "build-1234", b: "build-999", pattern: buildPattern, expected: 1, canCompare: true},
+		{name: "date pattern", a: "2025-01-09", b: "2025-02-01", pattern: datePattern, expected: -1, canCompare: true},
+		{name: "semver and pattern can't be compared", a: "v1.2.3", b: "build-999", pattern: buildPattern, canCompare: false},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			a, ok := Parse(tt.a, tt.pattern)
+			if !ok {
+				t.Fatalf("couldn't parse %q", tt.a)
+			}
+			b, ok := Parse(tt.b, tt.pattern)
+			if !ok {
+				t.Fatalf("couldn't parse %q", tt.b)
+			}
+
+			got, canCompare := Compare(a, b)
+
+			if canCompare != tt.canCompare {
+				t.Fatalf("got canCompare: %v, expected: %v", canCompare, tt.canCompare)
+			}
+			if got != tt.expected {
+				t.Errorf("got: %d, expected: %d", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseFailsForUnknownFormats(t *testing.T) {
+	for _, raw := range []string{"latest", "main-abc123", "1.2", "build-x"} {
+		if _, ok := Parse(raw, regexp.MustCompile(`^build-(\d+)$`)); ok {
+			t.Errorf("expected %q to not be parseable", raw)
+		}
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	envSequence := []string{"qa", "staging", "prod"}
+
+	testCases := []struct {
+		name              string
+		versions          map[string]string
+		expected          []string
+		expectedAnomaly   bool
+		expectedOutOfSync int
+	}{
+		{
+			name:     "all in sync",
+			versions: map[string]string{"qa": "v1.0.0", "staging": "v1.0.0", "prod": "v1.0.0"},
+			expected: []string{"qa and staging are in sync", "staging and prod are in sync"},
+		},
+		{
+			name:              "upstream ahead",
+			versions:          map[string]string{"qa": "v1.2.0", "staging": "v1.0.0", "prod": "v1.0.0"},
+			expected:          []string{"qa is ahead of staging by 2 minor versions", "staging and prod are in sync"},
+			expectedOutOfSync: 1,
+		},
+		{
+			name:              "downstream ahead",
+			versions:          map[string]string{"qa": "v1.1.0", "staging": "v1.1.0", "prod": "v1.1.1"},
+			expected:          []string{"qa and staging are in sync", "prod is ahead of staging by 1 patch version"},
+			expectedAnomaly:   true,
+			expectedOutOfSync: 1,
+		},
+		{
+			name:              "missing env is skipped",
+			versions:          map[string]string{"qa": "v1.0.0", "prod": "v2.0.0"},
+			expected:          []string{"prod is ahead of qa by 1 major version"},
+			expectedAnomaly:   true,
+			expectedOutOfSync: 1,
+		},
+		{
+			name:              "incomparable versions",
+			versions:          map[string]string{"qa": "main", "staging": "v1.0.0"},
+			expected:          []string{"qa (main) and staging (v1.0.0) can't be compared"},
+			expectedOutOfSync: 1,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			results := make(map[string]types.VersionResult)
+			for env, version := range tt.versions {
+				results[env] = types.VersionResult{Env: env, Version: version, Found: true}
+			}
+
+			got := Analyze(envSequence, results, nil, time.Now())
+
+			if len(got.Drifts) != len(tt.expected) {
+				t.Fatalf("got %d drifts, expected %d", len(got.Drifts), len(tt.expected))
+			}
+			for i, d := range got.Drifts {
+				if d.String() != tt.expected[i] {
+					t.Errorf("got: %q, expected: %q", d.String(), tt.expected[i])
+				}
+			}
+			if got.HasAnomaly() != tt.expectedAnomaly {
+				t.Errorf("got anomaly: %v, expected: %v", got.HasAnomaly(), tt.expectedAnomaly)
+			}
+			if len(got.OutOfSync()) != tt.expectedOutOfSync {
+				t.Errorf("got %d out of sync, expected %d", len(got.OutOfSync()), tt.expectedOutOfSync)
+			}
+		})
+	}
+}
+
+func TestAnalyzeComputesPromotionLag(t *testing.T) {
+	now := time.Date(2025, 3, 10, 10, 0, 0, 0, time.UTC)
+	nineDaysAgo := now.Add(-9 * 24 * time.Hour)
+	monthAgo := now.Add(-30 * 24 * time.Hour)
+	results := map[string]types.VersionResult{
+		"staging": {Env: "staging", Version: "v1.3.0", Found: true, RegisteredAt: &nineDaysAgo},
+		"prod":    {Env: "prod", Version: "v1.2.0", Found: true, RegisteredAt: &monthAgo},
+	}
+
+	got := Analyze([]string{"staging", "prod"}, results, nil, now)
+
+	if len(got.Drifts) != 1 {
+		t.Fatalf("got %d drifts, expected 1", len(got.Drifts))
+	}
+	if !got.Drifts[0].HasLag || got.Drifts[0].Lag != 9*24*time.Hour {
+		t.Errorf("got lag: %v (present: %v), expected: 9d", got.Drifts[0].Lag, got.Drifts[0].HasLag)
+	}
+	expected := "staging has been ahead of prod by 1 minor version for 9d"
+	if got.Drifts[0].String() != expected {
+		t.Errorf("got: %q, expected: %q", got.Drifts[0].String(), expected)
+	}
+}
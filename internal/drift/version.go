@@ -0,0 +1,164 @@
+package drift
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var semverRegex = regexp.MustCompile(`^v?(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(?:-([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?$`)
+
+type Kind uint
+
+const (
+	SemverKind Kind = iota
+	PatternKind
+)
+
+// Version is a version string broken down into numeric components that can be
+// compared with each other. For semver versions, the components are major,
+// minor and patch; for versions parsed via a fallback pattern, they are the
+// pattern's capture groups, in order.
+type Version struct {
+	Raw        string
+	Kind       Kind
+	Components []int
+	PreRelease string
+}
+
+// Parse parses a version as semver, and falls back to the provided pattern (if
+// any) when that doesn't work.
+func Parse(raw string, fallback *regexp.Regexp) (Version, bool) {
+	var zero Version
+
+	if matches := semverRegex.FindStringSubmatch(raw); matches != nil {
+		components, ok := toInts(matches[1:4])
+		if !ok {
+			return zero, false
+		}
+		return Version{
+			Raw:        raw,
+			Kind:       SemverKind,
+			Components: components,
+			PreRelease: matches[4],
+		}, true
+	}
+
+	if fallback == nil {
+		return zero, false
+	}
+
+	matches := fallback.FindStringSubmatch(raw)
+	if len(matches) < 2 {
+		return zero, false
+	}
+
+	components, ok := toInts(matches[1:])
+	if !ok {
+		return zero, false
+	}
+
+	return Version{
+		Raw:        raw,
+		Kind:       PatternKind,
+		Components: components,
+	}, true
+}
+
+// Compare returns -1, 0, or +1 depending on whether a is older than, the same
+// as, or newer than b. The second return value is false if the versions can't
+// be compared with each other.
+func Compare(a, b Version) (int, bool) {
+	if a.Kind != b.Kind || len(a.Components) != len(b.Components) {
+		return 0, false
+	}
+
+	for i := range a.Components {
+		if a.Components[i] != b.Components[i] {
+			return sign(a.Components[i] - b.Components[i]), true
+		}
+	}
+
+	return comparePreRelease(a.PreRelease, b.PreRelease), true
+}
+
+// CompareRaw parses two version strings and compares them via Compare.
+func CompareRaw(a, b string, fallback *regexp.Regexp) (int, bool) {
+	if a == b {
+		return 0, true
+	}
+
+	av, ok := Parse(a, fallback)
+	if !ok {
+		return 0, false
+	}
+
+	bv, ok := Parse(b, fallback)
+	if !ok {
+		return 0, false
+	}
+
+	return Compare(av, bv)
+}
+
+// comparePreRelease follows semver's precedence rules for pre-release
+// identifiers, where a version without a pre-release is newer than one with it.
+func comparePreRelease(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return 1
+	case b == "":
+		return -1
+	}
+
+	aIDs := strings.Split(a, ".")
+	bIDs := strings.Split(b, ".")
+
+	for i := 0; i < len(aIDs) && i < len(bIDs); i++ {
+		aNum, aErr := strconv.Atoi(aIDs[i])
+		bNum, bErr := strconv.Atoi(bIDs[i])
+
+		switch {
+		case aErr == nil && bErr == nil:
+			if aNum != bNum {
+				return sign(aNum - bNum)
+			}
+		case aErr == nil:
+			return -1
+		case bErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(aIDs[i], bIDs[i]); c != 0 {
+				return c
+			}
+		}
+	}
+
+	return sign(len(aIDs) - len(bIDs))
+}
+
+func toInts(values []string) ([]int, bool) {
+	ints := make([]int, len(values))
+	for i, v := range values {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, false
+		}
+		ints[i] = n
+	}
+
+	return ints, true
+}
+
+func sign(n int) int {
+	switch {
+	case n > 0:
+		return 1
+	case n < 0:
+		return -1
+	default:
+		return 0
+	}
+}
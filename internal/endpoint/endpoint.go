@@ -0,0 +1,218 @@
+package endpoint
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dhth/ecsv/internal/logging"
+	"github.com/dhth/ecsv/internal/types"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// maxBodySize caps how much of a response is read, since version endpoints
+// are expected to respond with small payloads
+const maxBodySize = 1 << 20
+
+var (
+	errEndpointMissing      = errors.New("no endpoint set for the http source")
+	errUnexpectedStatus     = errors.New("unexpected status")
+	errVersionIsEmpty       = errors.New("version is empty")
+	errBodyIsNotJSON        = errors.New("response body is not valid JSON")
+	errJSONPathIsInvalid    = errors.New("invalid JSONPath")
+	errJSONPathNoMatch      = errors.New("JSONPath didn't match anything")
+	errJSONPathNotScalar    = errors.New("JSONPath needs to match a string or a number")
+	errRegexIsInvalid       = errors.New("invalid regex")
+	errRegexNoMatch         = errors.New("regex didn't match the response body")
+	errVersionFromIsInvalid = errors.New("version-from is not supported for the http source")
+)
+
+// Source fetches versions by calling an HTTP endpoint, and extracting the
+// version from its response.
+type Source struct {
+	client *http.Client
+}
+
+func NewSource() *Source {
+	return &Source{client: &http.Client{}}
+}
+
+func (s *Source) FetchVersion(ctx context.Context, system types.VersionsConfig) types.VersionResult {
+	return FetchEndpointVersion(ctx, system, s.client)
+}
+
+// FetchEndpointVersion fetches the version of a system from the HTTP endpoint
+// set in its config. A 404 response means the version wasn't found.
+func FetchEndpointVersion(ctx context.Context, system types.VersionsConfig, client *http.Client) types.VersionResult {
+	result := types.VersionResult{
+		SystemKey: system.Key,
+		Env:       system.Env,
+	}
+
+	body, found, err := get(ctx, system, client)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	if !found {
+		return result
+	}
+
+	version, err := extractVersion(body, system.VersionFrom)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	result.Found = true
+	result.Version = version
+	return result
+}
+
+func get(ctx context.Context, system types.VersionsConfig, client *http.Client) ([]byte, bool, error) {
+	if system.HTTP == nil {
+		return nil, false, errEndpointMissing
+	}
+
+	ctx = logging.WithSystem(ctx, system.Key, system.Env)
+	ctx, cancel := context.WithTimeout(ctx, system.HTTP.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, system.HTTP.URL, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	for name, value := range system.HTTP.Headers {
+		req.Header.Set(name, value)
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	call := logging.RemoteCall{
+		Service:   "HTTP",
+		Operation: req.Method + " " + req.URL.Redacted(),
+		Err:       err,
+	}
+	defer func() {
+		call.Duration = time.Since(start)
+		logging.Call(ctx, call)
+	}()
+
+	if err != nil {
+		return nil, false, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, false, nil
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		call.Err = fmt.Errorf("%w: %s", errUnexpectedStatus, resp.Status)
+		return nil, false, types.CredentialsError{
+			Source: system.SourceDescription(),
+			Hint:   "check the auth headers sent to the endpoint",
+			Err:    call.Err,
+		}
+	case resp.StatusCode >= http.StatusBadRequest:
+		call.Err = fmt.Errorf("%w: %s", errUnexpectedStatus, resp.Status)
+		return nil, false, call.Err
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+	if err != nil {
+		call.Err = err
+		return nil, false, err
+	}
+
+	return body, true, nil
+}
+
+func extractVersion(body []byte, versionFrom types.VersionFrom) (string, error) {
+	var version string
+	var err error
+
+	switch versionFrom.Kind {
+	case types.ResponseBodyVersion:
+		version = string(body)
+	case types.JSONPathVersion:
+		version, err = jsonPathVersion(body, versionFrom.Name)
+	case types.RegexVersion:
+		version, err = regexVersion(body, versionFrom.Name)
+	default:
+		err = fmt.Errorf("%w: %s", errVersionFromIsInvalid, versionFrom)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	version = strings.TrimSpace(version)
+	if version == "" {
+		return "", errVersionIsEmpty
+	}
+
+	return version, nil
+}
+
+// jsonPathVersion extracts a version from a JSON body. path can either be in
+// the kubectl style (eg. "{.build.version}"), or without braces (eg.
+// "$.build.version").
+func jsonPathVersion(body []byte, path string) (string, error) {
+	if !strings.HasPrefix(path, "{") {
+		path = "{" + path + "}"
+	}
+
+	jp := jsonpath.New("version")
+	if err := jp.Parse(path); err != nil {
+		return "", fmt.Errorf("%w: %s", errJSONPathIsInvalid, err.Error())
+	}
+
+	var data any
+	if err := json.Unmarshal(body, &data); err != nil {
+		return "", fmt.Errorf("%w: %s", errBodyIsNotJSON, err.Error())
+	}
+
+	results, err := jp.FindResults(data)
+	if err != nil || len(results) == 0 || len(results[0]) == 0 {
+		return "", fmt.Errorf("%w: %s", errJSONPathNoMatch, path)
+	}
+
+	switch value := results[0][0].Interface().(type) {
+	case string:
+		return value, nil
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), nil
+	default:
+		return "", fmt.Errorf("%w: %s", errJSONPathNotScalar, path)
+	}
+}
+
+// regexVersion extracts a version from a body using a regex; the first
+// capture group is the version if there is one, and the entire match
+// otherwise.
+func regexVersion(body []byte, pattern string) (string, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", errRegexIsInvalid, err.Error())
+	}
+
+	match := re.FindSubmatch(body)
+	if match == nil {
+		return "", errRegexNoMatch
+	}
+
+	if len(match) > 1 {
+		return string(match[1]), nil
+	}
+
+	return string(match[0]), nil
+}
@@ -0,0 +1,176 @@
+package endpoint
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dhth/ecsv/internal/types"
+)
+
+func TestFetchEndpointVersion(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/version", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("1.4.2\n"))
+	})
+	mux.HandleFunc("/actuator/info", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"build": {"version": "1.4.1", "number": 112}}`))
+	})
+	mux.HandleFunc("/status", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`<p>running payments-api v1.4.0 since yesterday</p>`))
+	})
+	mux.HandleFunc("/private", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte("1.3.9"))
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+		_, _ = w.Write([]byte("1.4.2"))
+	})
+	mux.HandleFunc("/broken", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	testCases := []struct {
+		name        string
+		path        string
+		headers     map[string]string
+		timeout     time.Duration
+		versionFrom types.VersionFrom
+		expected    types.VersionResult
+		err         error
+		// credentialsErr is set if a types.CredentialsError is expected
+		credentialsErr bool
+	}{
+		{
+			name:        "plain body",
+			path:        "/version",
+			versionFrom: types.VersionFrom{Kind: types.ResponseBodyVersion},
+			expected:    types.VersionResult{Found: true, Version: "1.4.2"},
+		},
+		{
+			name:        "json path",
+			path:        "/actuator/info",
+			versionFrom: types.VersionFrom{Kind: types.JSONPathVersion, Name: "$.build.version"},
+			expected:    types.VersionResult{Found: true, Version: "1.4.1"},
+		},
+		{
+			name:        "json path to a number",
+			path:        "/actuator/info",
+			versionFrom: types.VersionFrom{Kind: types.JSONPathVersion, Name: "{.build.number}"},
+			expected:    types.VersionResult{Found: true, Version: "112"},
+		},
+		{
+			name:        "json path without a match",
+			path:        "/actuator/info",
+			versionFrom: types.VersionFrom{Kind: types.JSONPathVersion, Name: ".git.commit"},
+			err:         errJSONPathNoMatch,
+		},
+		{
+			name:        "json path to an object",
+			path:        "/actuator/info",
+			versionFrom: types.VersionFrom{Kind: types.JSONPathVersion, Name: ".build"},
+			err:         errJSONPathNotScalar,
+		},
+		{
+			name:        "regex",
+			path:        "/status",
+			versionFrom: types.VersionFrom{Kind: types.RegexVersion, Name: `payments-api v(\d+\.\d+\.\d+)`},
+			expected:    types.VersionResult{Found: true, Version: "1.4.0"},
+		},
+		{
+			name:        "regex without a match",
+			path:        "/status",
+			versionFrom: types.VersionFrom{Kind: types.RegexVersion, Name: `orders-api v(\S+)`},
+			err:         errRegexNoMatch,
+		},
+		{
+			name:        "auth headers",
+			path:        "/private",
+			headers:     map[string]string{"Authorization": "Bearer secret"},
+			versionFrom: types.VersionFrom{Kind: types.ResponseBodyVersion},
+			expected:    types.VersionResult{Found: true, Version: "1.3.9"},
+		},
+		{
+			name:           "missing auth headers",
+			path:           "/private",
+			versionFrom:    types.VersionFrom{Kind: types.ResponseBodyVersion},
+			credentialsErr: true,
+		},
+		{
+			name:        "not found",
+			path:        "/missing",
+			versionFrom: types.VersionFrom{Kind: types.ResponseBodyVersion},
+			expected:    types.VersionResult{Found: false},
+		},
+		{
+			name:        "server error",
+			path:        "/broken",
+			versionFrom: types.VersionFrom{Kind: types.ResponseBodyVersion},
+			err:         errUnexpectedStatus,
+		},
+		{
+			name:        "timeout",
+			path:        "/slow",
+			timeout:     20 * time.Millisecond,
+			versionFrom: types.VersionFrom{Kind: types.ResponseBodyVersion},
+			err:         context.DeadlineExceeded,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			timeout := tt.timeout
+			if timeout == 0 {
+				timeout = time.Second
+			}
+
+			system := types.VersionsConfig{
+				Key:    "payments-api",
+				Env:    "qa",
+				Source: types.HTTPSource,
+				HTTP: &types.HTTPEndpoint{
+					URL:     server.URL + tt.path,
+					Headers: tt.headers,
+					Timeout: timeout,
+				},
+				VersionFrom: tt.versionFrom,
+			}
+
+			got := FetchEndpointVersion(context.Background(), system, server.Client())
+
+			switch {
+			case tt.err != nil:
+				if !errors.Is(got.Err, tt.err) {
+					t.Fatalf("expected error %q, got %v", tt.err, got.Err)
+				}
+			case tt.credentialsErr:
+				var credentialsErr types.CredentialsError
+				if !errors.As(got.Err, &credentialsErr) {
+					t.Fatalf("expected a credentials error, got %v", got.Err)
+				}
+			default:
+				if got.Err != nil {
+					t.Fatalf("unexpected error: %v", got.Err)
+				}
+
+				if got.Found != tt.expected.Found || got.Version != tt.expected.Version {
+					t.Errorf("got: %+v, expected: %+v", got, tt.expected)
+				}
+			}
+		})
+	}
+}
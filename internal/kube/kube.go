@@ -0,0 +1,187 @@
+package kube
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dhth/ecsv/internal/logging"
+	"github.com/dhth/ecsv/internal/types"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+var errContainerNotSpecified = errors.New("deployment has more than one container; container-name needs to be set")
+
+// Source fetches versions from the image tag of a container in a Kubernetes
+// deployment, using the contexts in the kubeconfig.
+type Source struct {
+	mu        sync.Mutex
+	clients   map[string]*clientEntry
+	newClient func(kubeContext string) (kubernetes.Interface, error)
+}
+
+type clientEntry struct {
+	once   sync.Once
+	client kubernetes.Interface
+	err    error
+}
+
+func NewSource() *Source {
+	return &Source{
+		clients:   make(map[string]*clientEntry),
+		newClient: NewClient,
+	}
+}
+
+func (s *Source) FetchVersion(ctx context.Context, system types.VersionsConfig) types.VersionResult {
+	client, err := s.client(system)
+	if err != nil {
+		return types.VersionResult{
+			SystemKey: system.Key,
+			Env:       system.Env,
+			Err:       err,
+		}
+	}
+
+	return FetchDeploymentVersion(ctx, system, client)
+}
+
+// client returns the client for system's kube context, setting it up once per
+// context; errors are wrapped in a types.CredentialsError.
+func (s *Source) client(system types.VersionsConfig) (kubernetes.Interface, error) {
+	s.mu.Lock()
+	entry, ok := s.clients[system.KubeContext]
+	if !ok {
+		entry = &clientEntry{}
+		s.clients[system.KubeContext] = entry
+	}
+	s.mu.Unlock()
+
+	entry.once.Do(func() {
+		entry.client, entry.err = s.newClient(system.KubeContext)
+		if entry.err != nil {
+			entry.err = types.CredentialsError{
+				Source: system.SourceDescription(),
+				Hint:   "check that the context exists in your kubeconfig",
+				Err:    entry.err,
+			}
+		}
+	})
+
+	return entry.client, entry.err
+}
+
+// NewClient sets up a client for a context in the kubeconfig (as determined by
+// $KUBECONFIG, or ~/.kube/config). The current context is used if kubeContext
+// is empty.
+func NewClient(kubeContext string) (kubernetes.Interface, error) {
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		clientcmd.NewDefaultClientConfigLoadingRules(),
+		&clientcmd.ConfigOverrides{CurrentContext: kubeContext},
+	)
+
+	restConfig, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	return kubernetes.NewForConfig(restConfig)
+}
+
+// FetchDeploymentVersion fetches the version of a system that runs as a
+// Kubernetes deployment.
+func FetchDeploymentVersion(ctx context.Context, system types.VersionsConfig, client kubernetes.Interface) types.VersionResult {
+	ctx = logging.WithSystem(ctx, system.Key, system.Env)
+
+	start := time.Now()
+	deployment, err := client.AppsV1().Deployments(system.Namespace).Get(ctx, system.DeploymentName, metav1.GetOptions{})
+	logging.Call(ctx, logging.RemoteCall{
+		Service:   "Kubernetes",
+		Operation: "GetDeployment",
+		Duration:  time.Since(start),
+		Err:       err,
+	})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return types.VersionResult{
+				SystemKey: system.Key,
+				Env:       system.Env,
+				Found:     false,
+			}
+		}
+
+		return types.VersionResult{
+			SystemKey: system.Key,
+			Env:       system.Env,
+			Err:       wrapFetchError(system, err),
+		}
+	}
+
+	containers := deployment.Spec.Template.Spec.Containers
+	if system.ContainerName == "" && len(containers) > 1 {
+		return types.VersionResult{
+			SystemKey: system.Key,
+			Env:       system.Env,
+			Err:       fmt.Errorf("%w: %s", errContainerNotSpecified, system.DeploymentName),
+		}
+	}
+
+	for _, container := range containers {
+		if system.ContainerName == "" || container.Name == system.ContainerName {
+			return types.VersionResult{
+				Found:     true,
+				SystemKey: system.Key,
+				Env:       system.Env,
+				Version:   imageTag(container.Image),
+			}
+		}
+	}
+
+	return types.VersionResult{
+		SystemKey: system.Key,
+		Env:       system.Env,
+		Found:     false,
+	}
+}
+
+// wrapFetchError turns errors caused by a kube context's credentials into a
+// types.CredentialsError, so that they're reported once for all entries that
+// use the context.
+func wrapFetchError(system types.VersionsConfig, err error) error {
+	var hint string
+	switch {
+	case apierrors.IsUnauthorized(err):
+		hint = "credentials for the context are invalid or have expired"
+	case apierrors.IsForbidden(err):
+		hint = "credentials are missing the required permissions"
+	default:
+		return err
+	}
+
+	return types.CredentialsError{
+		Source: system.SourceDescription(),
+		Hint:   hint,
+		Err:    err,
+	}
+}
+
+// imageTag returns the tag of an image reference, eg. "1.2.3" for
+// "registry.example.com:5000/service-a:1.2.3". Digests are left as is.
+func imageTag(image string) string {
+	if i := strings.LastIndex(image, "@"); i >= 0 {
+		return image[i+1:]
+	}
+
+	name := image[strings.LastIndex(image, "/")+1:]
+	if i := strings.LastIndex(name, ":"); i >= 0 {
+		return name[i+1:]
+	}
+
+	return "latest"
+}
@@ -0,0 +1,127 @@
+package kube
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dhth/ecsv/internal/types"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func deployment(namespace, name string, containers ...corev1.Container) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{Containers: containers},
+			},
+		},
+	}
+}
+
+func TestFetchDeploymentVersion(t *testing.T) {
+	client := fake.NewClientset(
+		deployment("payments", "api",
+			corev1.Container{Name: "api", Image: "registry.example.com:5000/payments-api:1.4.2"},
+			corev1.Container{Name: "proxy", Image: "envoyproxy/envoy:v1.30.1"},
+		),
+		deployment("default", "worker",
+			corev1.Container{Name: "worker", Image: "payments-worker:2.0.0"},
+		),
+	)
+
+	testCases := []struct {
+		name          string
+		namespace     string
+		deployment    string
+		containerName string
+		expected      types.VersionResult
+		err           error
+	}{
+		{
+			name:          "container in deployment",
+			namespace:     "payments",
+			deployment:    "api",
+			containerName: "api",
+			expected:      types.VersionResult{Found: true, Version: "1.4.2"},
+		},
+		{
+			name:       "single container deployment",
+			namespace:  "default",
+			deployment: "worker",
+			expected:   types.VersionResult{Found: true, Version: "2.0.0"},
+		},
+		{
+			name:          "missing container",
+			namespace:     "payments",
+			deployment:    "api",
+			containerName: "sidecar",
+			expected:      types.VersionResult{Found: false},
+		},
+		{
+			name:       "missing deployment",
+			namespace:  "default",
+			deployment: "api",
+			expected:   types.VersionResult{Found: false},
+		},
+		{
+			name:       "container not specified for multi container deployment",
+			namespace:  "payments",
+			deployment: "api",
+			err:        errContainerNotSpecified,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			system := types.VersionsConfig{
+				Key:            "payments",
+				Env:            "qa",
+				Source:         types.KubernetesSource,
+				Namespace:      tt.namespace,
+				DeploymentName: tt.deployment,
+				ContainerName:  tt.containerName,
+			}
+
+			got := FetchDeploymentVersion(context.Background(), system, client)
+			if tt.err != nil {
+				if !errors.Is(got.Err, tt.err) {
+					t.Fatalf("expected error %q, got %v", tt.err, got.Err)
+				}
+				return
+			}
+
+			if got.Err != nil {
+				t.Fatalf("unexpected error: %v", got.Err)
+			}
+
+			if got.Found != tt.expected.Found || got.Version != tt.expected.Version {
+				t.Errorf("got: %+v, expected: %+v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestImageTag(t *testing.T) {
+	testCases := []struct {
+		image    string
+		expected string
+	}{
+		{image: "payments-api:1.4.2", expected: "1.4.2"},
+		{image: "registry.example.com:5000/payments-api:1.4.2", expected: "1.4.2"},
+		{image: "registry.example.com:5000/payments-api", expected: "latest"},
+		{image: "payments-api@sha256:abc123", expected: "sha256:abc123"},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.image, func(t *testing.T) {
+			if got := imageTag(tt.image); got != tt.expected {
+				t.Errorf("got %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"time"
+)
+
+var logger = slog.New(slog.DiscardHandler)
+
+// Enable makes remote calls get logged to w, as JSON if asJSON is true, and
+// as logfmt style text otherwise.
+func Enable(w io.Writer, asJSON bool) {
+	if asJSON {
+		logger = slog.New(slog.NewJSONHandler(w, nil))
+		return
+	}
+
+	logger = slog.New(slog.NewTextHandler(w, nil))
+}
+
+// Enabled reports whether remote calls are being logged.
+func Enabled() bool {
+	return logger.Handler() != slog.DiscardHandler
+}
+
+type systemKey struct{}
+
+type system struct {
+	key string
+	env string
+}
+
+// WithSystem annotates ctx with the system and env that remote calls made
+// using it are for, so that they're logged alongside the calls.
+func WithSystem(ctx context.Context, key, env string) context.Context {
+	return context.WithValue(ctx, systemKey{}, system{key: key, env: env})
+}
+
+// RemoteCall describes a single call made to AWS or GitHub.
+type RemoteCall struct {
+	Service   string
+	Operation string
+	Duration  time.Duration
+	// Retries is the number of times the call was retried
+	Retries int
+	Err     error
+}
+
+// Call logs a remote call, along with the system and env it was made for (if
+// any).
+func Call(ctx context.Context, call RemoteCall) {
+	attrs := []slog.Attr{
+		slog.String("service", call.Service),
+		slog.String("operation", call.Operation),
+	}
+
+	if s, ok := ctx.Value(systemKey{}).(system); ok {
+		attrs = append(attrs, slog.String("system", s.key), slog.String("env", s.env))
+	}
+
+	attrs = append(attrs,
+		slog.Duration("duration", call.Duration),
+		slog.Int("retries", call.Retries),
+	)
+
+	if call.Err != nil {
+		attrs = append(attrs, slog.String("error", call.Err.Error()))
+		logger.LogAttrs(ctx, slog.LevelError, "remote call failed", attrs...)
+		return
+	}
+
+	logger.LogAttrs(ctx, slog.LevelInfo, "remote call", attrs...)
+}
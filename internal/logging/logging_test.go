@@ -0,0 +1,49 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestCallLogsSystemAndError(t *testing.T) {
+	var buf bytes.Buffer
+	Enable(&buf, true)
+	t.Cleanup(func() {
+		logger = slog.New(slog.DiscardHandler)
+	})
+
+	ctx := WithSystem(context.Background(), "service-a", "qa")
+	Call(ctx, RemoteCall{
+		Service:   "ECS",
+		Operation: "DescribeServices",
+		Duration:  150 * time.Millisecond,
+		Retries:   2,
+		Err:       errors.New("throttled"),
+	})
+
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("couldn't parse log line %q: %s", buf.String(), err.Error())
+	}
+
+	expected := map[string]any{
+		"level":     "ERROR",
+		"service":   "ECS",
+		"operation": "DescribeServices",
+		"system":    "service-a",
+		"env":       "qa",
+		"duration":  float64(150 * time.Millisecond),
+		"retries":   float64(2),
+		"error":     "throttled",
+	}
+	for key, value := range expected {
+		if got[key] != value {
+			t.Errorf("got %s: %v, expected: %v", key, got[key], value)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package policy
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/dhth/ecsv/internal/drift"
+	"github.com/dhth/ecsv/internal/types"
+	"github.com/dhth/ecsv/internal/utils"
+)
+
+// Violation is a breach of a policy by a system in a particular env.
+type Violation struct {
+	Policy    string
+	Rule      types.PolicyRule
+	SystemKey string
+	Env       string
+	Message   string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s", v.Policy, v.Message)
+}
+
+// Evaluate checks the versions fetched for each system against the policies
+// in the config, and returns the violations in the order of systemKeys.
+func Evaluate(config types.Config,
+	systemKeys []string,
+	envSequence []string,
+	versionResults map[string]map[string]types.VersionResult,
+	now time.Time,
+) []Violation {
+	var violations []Violation
+
+	for _, sys := range systemKeys {
+		results, ok := versionResults[sys]
+		if !ok {
+			continue
+		}
+
+		for _, p := range config.Policies {
+			if !p.AppliesTo(sys, config.Metadata[sys].Tags) {
+				continue
+			}
+
+			switch p.Rule {
+			case types.MaxLagRule:
+				violations = append(violations, evaluateMaxLag(p, sys, results, now)...)
+			case types.NoDownstreamAheadRule:
+				violations = append(violations, evaluateNoDownstreamAhead(p, sys, results, config.VersionPatterns[sys])...)
+			case types.InSyncRule:
+				violations = append(violations, evaluateInSync(p, sys, envSequence, results)...)
+			case types.ForbiddenVersionRule:
+				violations = append(violations, evaluateForbiddenVersion(p, sys, results)...)
+			}
+		}
+	}
+
+	return violations
+}
+
+// evaluateMaxLag measures how long upstream and downstream have been running
+// different versions by when the more recently deployed of the two was
+// registered.
+func evaluateMaxLag(p types.Policy, systemKey string, results map[string]types.VersionResult, now time.Time) []Violation {
+	upstream, ok := usable(results, p.Upstream)
+	if !ok {
+		return nil
+	}
+	downstream, ok := usable(results, p.Downstream)
+	if !ok {
+		return nil
+	}
+
+	if upstream.Version == downstream.Version || upstream.RegisteredAt == nil {
+		return nil
+	}
+
+	// the versions started differing at the latest when the more recent of
+	// the two was deployed; older deployments in between aren't known, so
+	// this can under-report the lag, but never over-report it
+	differingSince := *upstream.RegisteredAt
+	if downstream.RegisteredAt != nil && downstream.RegisteredAt.After(differingSince) {
+		differingSince = *downstream.RegisteredAt
+	}
+
+	lag := now.Sub(differingSince)
+	if lag <= p.MaxLag {
+		return nil
+	}
+
+	return []Violation{{
+		Policy:    p.Name,
+		Rule:      p.Rule,
+		SystemKey: systemKey,
+		Env:       p.Downstream,
+		Message:   fmt.Sprintf("%s has differed from %s for %s (max: %s)", p.Downstream, p.Upstream, utils.HumanizeDuration(lag), utils.HumanizeDuration(p.MaxLag)),
+	}}
+}
+
+func evaluateNoDownstreamAhead(p types.Policy, systemKey string, results map[string]types.VersionResult, fallback *regexp.Regexp) []Violation {
+	upstream, ok := usable(results, p.Upstream)
+	if !ok {
+		return nil
+	}
+	downstream, ok := usable(results, p.Downstream)
+	if !ok {
+		return nil
+	}
+
+	cmp, ok := drift.CompareRaw(downstream.Version, upstream.Version, fallback)
+	if !ok || cmp <= 0 {
+		return nil
+	}
+
+	return []Violation{{
+		Policy:    p.Name,
+		Rule:      p.Rule,
+		SystemKey: systemKey,
+		Env:       p.Downstream,
+		Message:   fmt.Sprintf("%s (%s) is ahead of %s (%s)", p.Downstream, downstream.Version, p.Upstream, upstream.Version),
+	}}
+}
+
+func evaluateInSync(p types.Policy, systemKey string, envSequence []string, results map[string]types.VersionResult) []Violation {
+	envs := p.Envs
+	if len(envs) == 0 {
+		envs = envSequence
+	}
+
+	var reference *types.VersionResult
+	var violations []Violation
+	for _, env := range envs {
+		r, ok := results[env]
+		if !ok || r.Err != nil {
+			continue
+		}
+
+		if !r.Found {
+			violations = append(violations, Violation{
+				Policy:    p.Name,
+				Rule:      p.Rule,
+				SystemKey: systemKey,
+				Env:       env,
+				Message:   fmt.Sprintf("%s is not running in %s", systemKey, env),
+			})
+			continue
+		}
+
+		if reference == nil {
+			reference = &r
+			continue
+		}
+
+		if r.Version != reference.Version {
+			violations = append(violations, Violation{
+				Policy:    p.Name,
+				Rule:      p.Rule,
+				SystemKey: systemKey,
+				Env:       env,
+				Message:   fmt.Sprintf("%s is running %s, while %s is running %s", env, r.Version, reference.Env, reference.Version),
+			})
+		}
+	}
+
+	return violations
+}
+
+func evaluateForbiddenVersion(p types.Policy, systemKey string, results map[string]types.VersionResult) []Violation {
+	var violations []Violation
+	for _, env := range p.Envs {
+		r, ok := usable(results, env)
+		if !ok {
+			continue
+		}
+
+		if p.Pattern.MatchString(r.Version) {
+			violations = append(violations, Violation{
+				Policy:    p.Name,
+				Rule:      p.Rule,
+				SystemKey: systemKey,
+				Env:       env,
+				Message:   fmt.Sprintf("%s is running a forbidden version: %s", env, r.Version),
+			})
+		}
+	}
+
+	return violations
+}
+
+func usable(results map[string]types.VersionResult, env string) (types.VersionResult, bool) {
+	r, ok := results[env]
+	if !ok || r.Err != nil || !r.Found || r.Version == "" {
+		return r, false
+	}
+
+	return r, true
+}
@@ -0,0 +1,182 @@
+package policy
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dhth/ecsv/internal/types"
+	"gopkg.in/yaml.v3"
+)
+
+const policyTestConfig = `
+env-sequence: ["qa", "staging", "prod"]
+systems:
+  - key: service-a
+    envs:
+      - name: qa
+        aws-config-source: default
+      - name: staging
+        aws-config-source: default
+      - name: prod
+        aws-config-source: default
+  - key: payments
+    tags: ["critical"]
+    envs:
+      - name: qa
+        aws-config-source: default
+      - name: staging
+        aws-config-source: default
+      - name: prod
+        aws-config-source: default
+policies:
+  - name: staging-prod-lag
+    rule: max-lag
+    upstream: staging
+    downstream: prod
+    max-lag: 7d
+  - name: prod-never-ahead
+    rule: no-downstream-ahead
+    upstream: staging
+    downstream: prod
+  - name: critical-in-sync
+    rule: in-sync
+    tags: ["critical"]
+  - name: no-snapshots-in-prod
+    rule: forbidden-version
+    envs: ["prod"]
+    pattern: "-SNAPSHOT$"
+`
+
+var errFetch = errors.New("token expired")
+
+func TestEvaluate(t *testing.T) {
+	now := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	tenDaysAgo := now.Add(-10 * 24 * time.Hour)
+	twoDaysAgo := now.Add(-2 * 24 * time.Hour)
+
+	var ecsvConfig types.ECSVConfig
+	if err := yaml.Unmarshal([]byte(policyTestConfig), &ecsvConfig); err != nil {
+		t.Fatalf("couldn't unmarshal config: %s", err.Error())
+	}
+	config, errs := ecsvConfig.Parse(types.Filters{})
+	if len(errs) > 0 {
+		t.Fatalf("couldn't parse config: %v", errs)
+	}
+
+	result := func(env, version string, registeredAt time.Time) types.VersionResult {
+		return types.VersionResult{Env: env, Version: version, Found: true, RegisteredAt: &registeredAt}
+	}
+
+	testCases := []struct {
+		name     string
+		results  map[string]map[string]types.VersionResult
+		expected []Violation
+	}{
+		{
+			name: "no violations",
+			results: map[string]map[string]types.VersionResult{
+				"service-a": {
+					"qa":      result("qa", "v1.1.0", twoDaysAgo),
+					"staging": result("staging", "v1.1.0", twoDaysAgo),
+					"prod":    result("prod", "v1.0.0", tenDaysAgo),
+				},
+			},
+		},
+		{
+			name: "lag exceeded",
+			results: map[string]map[string]types.VersionResult{
+				"service-a": {
+					"staging": result("staging", "v1.1.0", tenDaysAgo),
+					"prod":    result("prod", "v1.0.0", tenDaysAgo),
+				},
+			},
+			expected: []Violation{
+				{Policy: "staging-prod-lag", Rule: types.MaxLagRule, SystemKey: "service-a", Env: "prod", Message: "prod has differed from staging for 10d (max: 7d)"},
+			},
+		},
+		{
+			name: "lag measured from the more recent deployment",
+			results: map[string]map[string]types.VersionResult{
+				"service-a": {
+					"staging": result("staging", "v1.1.0", tenDaysAgo),
+					"prod":    result("prod", "v1.0.1", twoDaysAgo),
+				},
+			},
+		},
+		{
+			name: "downstream ahead and snapshot in prod",
+			results: map[string]map[string]types.VersionResult{
+				"service-a": {
+					"staging": result("staging", "v1.0.0", twoDaysAgo),
+					"prod":    result("prod", "v1.1.0-SNAPSHOT", twoDaysAgo),
+				},
+			},
+			expected: []Violation{
+				{Policy: "prod-never-ahead", Rule: types.NoDownstreamAheadRule, SystemKey: "service-a", Env: "prod", Message: "prod (v1.1.0-SNAPSHOT) is ahead of staging (v1.0.0)"},
+				{Policy: "no-snapshots-in-prod", Rule: types.ForbiddenVersionRule, SystemKey: "service-a", Env: "prod", Message: "prod is running a forbidden version: v1.1.0-SNAPSHOT"},
+			},
+		},
+		{
+			name: "in-sync only applies to matching systems",
+			results: map[string]map[string]types.VersionResult{
+				"service-a": {
+					"qa":      result("qa", "v1.2.0", twoDaysAgo),
+					"staging": result("staging", "v1.1.0", twoDaysAgo),
+				},
+				"payments": {
+					"qa":      result("qa", "v1.2.0", twoDaysAgo),
+					"staging": result("staging", "v1.1.0", twoDaysAgo),
+					"prod":    {Env: "prod", Err: errFetch},
+				},
+			},
+			expected: []Violation{
+				{Policy: "critical-in-sync", Rule: types.InSyncRule, SystemKey: "payments", Env: "staging", Message: "staging is running v1.1.0, while qa is running v1.2.0"},
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Evaluate(config, []string{"service-a", "payments"}, ecsvConfig.EnvSequence, tt.results, now)
+
+			if len(got) != len(tt.expected) {
+				t.Fatalf("got %d violations, expected %d; got: %v", len(got), len(tt.expected), got)
+			}
+			for i := range got {
+				if got[i] != tt.expected[i] {
+					t.Errorf("got: %+v, expected: %+v", got[i], tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParsingInvalidPoliciesFails(t *testing.T) {
+	testCases := []struct {
+		name   string
+		policy string
+	}{
+		{name: "unknown rule", policy: `{name: p, rule: unknown}`},
+		{name: "upstream after downstream", policy: `{name: p, rule: no-downstream-ahead, upstream: prod, downstream: qa}`},
+		{name: "invalid max-lag", policy: `{name: p, rule: max-lag, upstream: qa, downstream: prod, max-lag: a week}`},
+		{name: "unknown env", policy: `{name: p, rule: forbidden-version, envs: [dev], pattern: x}`},
+		{name: "missing pattern", policy: `{name: p, rule: forbidden-version, envs: [prod]}`},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			var ecsvConfig types.ECSVConfig
+			configStr := `{env-sequence: [qa, prod], policies: [` + tt.policy + `]}`
+			if err := yaml.Unmarshal([]byte(configStr), &ecsvConfig); err != nil {
+				t.Fatalf("couldn't unmarshal config: %s", err.Error())
+			}
+
+			_, errs := ecsvConfig.Parse(types.Filters{})
+
+			if len(errs) == 0 {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	errConcurrencyConfigIsIncorrect = errors.New("concurrency config is incorrect")
+	errConcurrencyLimitIsInvalid    = errors.New("max-concurrent-fetches needs to be greater than 0")
+	errConcurrencyLimitDuplicate    = errors.New("concurrency limit is set more than once")
+	errGitHubHostIsEmpty            = errors.New("host is empty")
+)
+
+type awsConcurrencyConfig struct {
+	AwsConfigSource      string `yaml:"aws-config-source" jsonschema:"required" desc:"AWS config source to limit fetches for, as used in envs"`
+	AwsRegion            string `yaml:"aws-region" desc:"AWS region to limit fetches for; applies to every region if not provided"`
+	MaxConcurrentFetches int    `yaml:"max-concurrent-fetches" jsonschema:"required" desc:"maximum number of fetches to run at the same time"`
+}
+
+type githubConcurrencyConfig struct {
+	Host                 string `yaml:"host" jsonschema:"required" desc:"GitHub host to limit fetches for, eg. \"github.com\""`
+	MaxConcurrentFetches int    `yaml:"max-concurrent-fetches" jsonschema:"required" desc:"maximum number of fetches to run at the same time"`
+}
+
+type concurrencyConfig struct {
+	AWS    []awsConcurrencyConfig    `yaml:"aws" desc:"limits on fetches per AWS config source and region"`
+	GitHub []githubConcurrencyConfig `yaml:"github" desc:"limits on fetches per GitHub host"`
+}
+
+// AWSConcurrencyLimit caps the number of concurrent fetches that use an AWS
+// config. An empty Region matches every region.
+type AWSConcurrencyLimit struct {
+	SourceType AWSConfigSourceType
+	Source     string
+	Region     string
+	Max        int
+}
+
+func (l AWSConcurrencyLimit) matches(vc VersionsConfig) bool {
+	return vc.Source.IsAWS() &&
+		l.SourceType == vc.AWSConfigSourceType &&
+		l.Source == vc.AWSConfigSource &&
+		(l.Region == "" || l.Region == vc.AWSRegion)
+}
+
+// ConcurrencyLimits holds the limits on concurrent fetches that apply on top
+// of the global one.
+type ConcurrencyLimits struct {
+	AWS []AWSConcurrencyLimit
+	// GitHub holds limits keyed by host
+	GitHub map[string]int
+}
+
+// ForAWSConfig returns the limit for fetches that use a config entry's AWS
+// config, preferring limits set for its region over ones that apply to every
+// region. It returns 0 if there's no limit, or if the entry's source doesn't
+// use AWS.
+func (l ConcurrencyLimits) ForAWSConfig(vc VersionsConfig) int {
+	var limit int
+	for _, a := range l.AWS {
+		if !a.matches(vc) {
+			continue
+		}
+		if a.Region != "" {
+			return a.Max
+		}
+		limit = a.Max
+	}
+
+	return limit
+}
+
+func (c concurrencyConfig) parse() (ConcurrencyLimits, []error) {
+	limits := ConcurrencyLimits{GitHub: make(map[string]int)}
+	var errs []error
+
+	seen := make(map[string]bool)
+	for _, a := range c.AWS {
+		sourceType, source, ok := parseAWSConfigSource(a.AwsConfigSource)
+		if !ok {
+			errs = append(errs, fmt.Errorf("%w: %w: %q", errConcurrencyConfigIsIncorrect, errInvalidConfigSourceProvided, a.AwsConfigSource))
+			continue
+		}
+
+		key := a.AwsConfigSource + "@" + a.AwsRegion
+		if seen[key] {
+			errs = append(errs, fmt.Errorf("%w: %w: %s", errConcurrencyConfigIsIncorrect, errConcurrencyLimitDuplicate, strings.TrimSuffix(key, "@")))
+			continue
+		}
+		seen[key] = true
+
+		if a.MaxConcurrentFetches <= 0 {
+			errs = append(errs, fmt.Errorf("%w: %w (aws-config-source: %s)", errConcurrencyConfigIsIncorrect, errConcurrencyLimitIsInvalid, a.AwsConfigSource))
+			continue
+		}
+
+		limits.AWS = append(limits.AWS, AWSConcurrencyLimit{
+			SourceType: sourceType,
+			Source:     source,
+			Region:     a.AwsRegion,
+			Max:        a.MaxConcurrentFetches,
+		})
+	}
+
+	seenHosts := make(map[string]bool)
+	for _, g := range c.GitHub {
+		host := strings.TrimSpace(g.Host)
+		switch {
+		case host == "":
+			errs = append(errs, fmt.Errorf("%w: %w", errConcurrencyConfigIsIncorrect, errGitHubHostIsEmpty))
+		case seenHosts[host]:
+			errs = append(errs, fmt.Errorf("%w: %w: %s", errConcurrencyConfigIsIncorrect, errConcurrencyLimitDuplicate, host))
+		case g.MaxConcurrentFetches <= 0:
+			errs = append(errs, fmt.Errorf("%w: %w (host: %s)", errConcurrencyConfigIsIncorrect, errConcurrencyLimitIsInvalid, host))
+		default:
+			limits.GitHub[host] = g.MaxConcurrentFetches
+		}
+		seenHosts[host] = true
+	}
+
+	return limits, errs
+}
@@ -0,0 +1,103 @@
+package types
+
+import (
+	"errors"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestParseConcurrencyLimits(t *testing.T) {
+	configStr := `
+concurrency:
+  aws:
+    - aws-config-source: profile:::prod
+      max-concurrent-fetches: 4
+    - aws-config-source: profile:::prod
+      aws-region: us-east-1
+      max-concurrent-fetches: 2
+  github:
+    - host: github.com
+      max-concurrent-fetches: 3
+`
+	var ecsvConfig ECSVConfig
+	if err := yaml.Unmarshal([]byte(configStr), &ecsvConfig); err != nil {
+		t.Fatalf("couldn't unmarshal config: %s", err.Error())
+	}
+
+	got, errs := ecsvConfig.Parse(Filters{})
+	if len(errs) > 0 {
+		t.Fatalf("got unexpected errors: %v", errs)
+	}
+
+	testCases := []struct {
+		name     string
+		config   VersionsConfig
+		expected int
+	}{
+		{
+			name:     "limit for all regions",
+			config:   VersionsConfig{Source: ECSSource, AWSConfigSourceType: SharedCfgProfileType, AWSConfigSource: "prod", AWSRegion: "eu-central-1"},
+			expected: 4,
+		},
+		{
+			name:     "region specific limit takes precedence",
+			config:   VersionsConfig{Source: ECSSource, AWSConfigSourceType: SharedCfgProfileType, AWSConfigSource: "prod", AWSRegion: "us-east-1"},
+			expected: 2,
+		},
+		{
+			name:     "no limit",
+			config:   VersionsConfig{Source: ECSSource, AWSConfigSourceType: SharedCfgProfileType, AWSConfigSource: "qa", AWSRegion: "eu-central-1"},
+			expected: 0,
+		},
+		{
+			name:     "not an AWS source",
+			config:   VersionsConfig{Source: KubernetesSource, AWSConfigSourceType: SharedCfgProfileType, AWSConfigSource: "prod"},
+			expected: 0,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			if limit := got.Concurrency.ForAWSConfig(tt.config); limit != tt.expected {
+				t.Errorf("got limit %d, expected %d", limit, tt.expected)
+			}
+		})
+	}
+
+	if got.Concurrency.GitHub["github.com"] != 3 {
+		t.Errorf("got GitHub limit %d, expected 3", got.Concurrency.GitHub["github.com"])
+	}
+}
+
+func TestParseReportsIncorrectConcurrencyLimits(t *testing.T) {
+	configStr := `
+concurrency:
+  aws:
+    - aws-config-source: profile:::prod
+      max-concurrent-fetches: 0
+    - aws-config-source: prod
+      max-concurrent-fetches: 2
+  github:
+    - host: github.com
+      max-concurrent-fetches: 3
+    - host: github.com
+      max-concurrent-fetches: 5
+`
+	var ecsvConfig ECSVConfig
+	if err := yaml.Unmarshal([]byte(configStr), &ecsvConfig); err != nil {
+		t.Fatalf("couldn't unmarshal config: %s", err.Error())
+	}
+
+	_, errs := ecsvConfig.Parse(Filters{})
+
+	expected := []error{errConcurrencyLimitIsInvalid, errInvalidConfigSourceProvided, errConcurrencyLimitDuplicate}
+	if len(errs) != len(expected) {
+		t.Fatalf("got %d errors, expected %d: %v", len(errs), len(expected), errs)
+	}
+	for i := range expected {
+		if !errors.Is(errs[i], expected[i]) {
+			t.Errorf("got error %q, expected it to be %q", errs[i], expected[i])
+		}
+	}
+}
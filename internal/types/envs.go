@@ -0,0 +1,141 @@
+package types
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+var errEnvFieldTemplateIncorrect = errors.New("couldn't render placeholders")
+
+// envDefaultsConfig holds the values that all systems inherit for an env,
+// unless they override them.
+type envDefaultsConfig struct {
+	Source          SourceKind        `yaml:"source" desc:"where to get the version from; defaults to ecs"`
+	AwsConfigSource string            `yaml:"aws-config-source" desc:"where to get AWS credentials from: \"default\", \"profile:::<profile>\", or \"assume-role:::<role-arn>\""`
+	AwsRegion       string            `yaml:"aws-region" desc:"AWS region the system runs in"`
+	Cluster         string            `yaml:"cluster" desc:"name of the ECS cluster (for the ecs source)"`
+	Service         string            `yaml:"service" desc:"name of the ECS service (for the ecs source)"`
+	ContainerName   string            `yaml:"container-name" desc:"name of the container whose image tag is the version (for the ecs and kubernetes sources); can be left out for deployments with a single container"`
+	Function        string            `yaml:"function" desc:"name of the Lambda function (for the lambda source)"`
+	FunctionAlias   string            `yaml:"function-alias" desc:"alias of the Lambda function, whose published version is to be checked (for the lambda source); $LATEST is checked if not provided"`
+	KubeContext     string            `yaml:"kube-context" desc:"context from the kubeconfig to use (for the kubernetes source); the current context is used if not provided"`
+	Namespace       string            `yaml:"namespace" desc:"namespace of the deployment (for the kubernetes source); defaults to \"default\""`
+	Deployment      string            `yaml:"deployment" desc:"name of the deployment (for the kubernetes source)"`
+	Parameter       string            `yaml:"parameter" desc:"name of the SSM parameter whose value is the version (for the ssm source)"`
+	Stack           string            `yaml:"stack" desc:"name of the CloudFormation stack (for the cloudformation source)"`
+	Output          string            `yaml:"output" desc:"key of the CloudFormation stack output whose value is the version (for the cloudformation source)"`
+	URL             string            `yaml:"url" desc:"URL that responds with the version (for the http source)"`
+	Headers         map[string]string `yaml:"headers" desc:"headers to send to url, eg. for auth (for the http source); values can refer to environment variables, eg. \"Bearer ${VERSION_TOKEN}\""`
+	Timeout         string            `yaml:"timeout" desc:"timeout of requests to url, eg. \"5s\" (for the http source); defaults to 10s"`
+	VersionFrom     string            `yaml:"version-from" desc:"where the version comes from: \"image\" (the image tag; default), \"tag:<name>\" (a Lambda function's tag), \"env:<name>\" (an environment variable of an ECS container or a Lambda function), \"label:<name>\" (a docker label of an ECS container), or for the http source, \"body\" (the response body; default), \"json:<jsonpath>\", or \"regex:<pattern>\" (the first capture group, or the entire match, in the response body); the ssm and cloudformation sources always use the parameter's or output's value"`
+}
+
+// envConfig is an env entry under a system. It can either be a mapping, or
+// just the env's name, in which case all values come from the env's defaults.
+type envConfig struct {
+	Name              string `yaml:"name" jsonschema:"required" desc:"name of the env, as present in env-sequence"`
+	envDefaultsConfig `yaml:",inline"`
+}
+
+func (e *envConfig) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		e.Name = value.Value
+		return nil
+	}
+
+	type plain envConfig
+	return value.Decode((*plain)(e))
+}
+
+// placeholderData is what the placeholders in an env's values can refer to,
+// eg. "{{.Key}}-{{.Env}}-Service".
+type placeholderData struct {
+	Key string
+	Env string
+}
+
+// resolve fills in the values missing from an env entry using the env's
+// defaults, and renders placeholders in the result.
+func (e envConfig) resolve(defaults envDefaultsConfig, systemKey string) (envConfig, error) {
+	resolved := envConfig{
+		Name:              e.Name,
+		envDefaultsConfig: defaults,
+	}
+
+	overrides := []struct {
+		value  string
+		target *string
+	}{
+		{string(e.Source), (*string)(&resolved.Source)},
+		{e.AwsConfigSource, &resolved.AwsConfigSource},
+		{e.AwsRegion, &resolved.AwsRegion},
+		{e.Cluster, &resolved.Cluster},
+		{e.Service, &resolved.Service},
+		{e.ContainerName, &resolved.ContainerName},
+		{e.Function, &resolved.Function},
+		{e.FunctionAlias, &resolved.FunctionAlias},
+		{e.KubeContext, &resolved.KubeContext},
+		{e.Namespace, &resolved.Namespace},
+		{e.Deployment, &resolved.Deployment},
+		{e.Parameter, &resolved.Parameter},
+		{e.Stack, &resolved.Stack},
+		{e.Output, &resolved.Output},
+		{e.URL, &resolved.URL},
+		{e.Timeout, &resolved.Timeout},
+		{e.VersionFrom, &resolved.VersionFrom},
+	}
+
+	// headers set for the env entry are added to the env's default ones
+	if len(defaults.Headers) > 0 || len(e.Headers) > 0 {
+		resolved.Headers = make(map[string]string, len(defaults.Headers)+len(e.Headers))
+		maps.Copy(resolved.Headers, defaults.Headers)
+		maps.Copy(resolved.Headers, e.Headers)
+	}
+
+	data := placeholderData{Key: systemKey, Env: e.Name}
+	for name, value := range resolved.Headers {
+		rendered, err := renderPlaceholders(value, data)
+		if err != nil {
+			return resolved, err
+		}
+		resolved.Headers[name] = rendered
+	}
+
+	for _, o := range overrides {
+		if o.value != "" {
+			*o.target = o.value
+		}
+
+		rendered, err := renderPlaceholders(*o.target, data)
+		if err != nil {
+			return resolved, err
+		}
+		*o.target = rendered
+	}
+
+	return resolved, nil
+}
+
+func renderPlaceholders(value string, data placeholderData) (string, error) {
+	if !strings.Contains(value, "{{") {
+		return value, nil
+	}
+
+	tmpl, err := template.New("value").Option("missingkey=error").Parse(value)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", errEnvFieldTemplateIncorrect, err.Error())
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("%w: %s", errEnvFieldTemplateIncorrect, err.Error())
+	}
+
+	return buf.String(), nil
+}
@@ -0,0 +1,82 @@
+package types
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestParseUsesEnvDefaults(t *testing.T) {
+	configStr := `
+env-sequence: ["qa", "staging"]
+envs:
+  qa:
+    aws-config-source: profile:::qa
+    aws-region: eu-central-1
+    cluster: 1brd-qa
+    service: "{{.Key}}-fargate"
+    container-name: "{{.Key}}-{{.Env}}-Service"
+  staging:
+    aws-config-source: profile:::staging
+    aws-region: eu-central-1
+    cluster: 1brd-staging
+    service: "{{.Key}}-fargate"
+    container-name: "{{.Key}}-{{.Env}}-Service"
+systems:
+  - key: service-a
+    envs: [qa, staging]
+  - key: service-b
+    envs:
+      - qa
+      - name: staging
+        cluster: 1brd-staging-2
+        container-name: "{{.Key}}-main"
+`
+	var ecsvConfig ECSVConfig
+	if err := yaml.Unmarshal([]byte(configStr), &ecsvConfig); err != nil {
+		t.Fatalf("couldn't unmarshal config: %s", err.Error())
+	}
+
+	got, errs := ecsvConfig.Parse(Filters{})
+	if len(errs) > 0 {
+		t.Fatalf("got unexpected errors: %v", errs)
+	}
+
+	expected := []VersionsConfig{
+		{Key: "service-a", Env: "qa", Source: ECSSource, AWSConfigSourceType: SharedCfgProfileType, AWSConfigSource: "qa", AWSRegion: "eu-central-1", ClusterName: "1brd-qa", ServiceName: "service-a-fargate", ContainerName: "service-a-qa-Service"},
+		{Key: "service-a", Env: "staging", Source: ECSSource, AWSConfigSourceType: SharedCfgProfileType, AWSConfigSource: "staging", AWSRegion: "eu-central-1", ClusterName: "1brd-staging", ServiceName: "service-a-fargate", ContainerName: "service-a-staging-Service"},
+		{Key: "service-b", Env: "qa", Source: ECSSource, AWSConfigSourceType: SharedCfgProfileType, AWSConfigSource: "qa", AWSRegion: "eu-central-1", ClusterName: "1brd-qa", ServiceName: "service-b-fargate", ContainerName: "service-b-qa-Service"},
+		{Key: "service-b", Env: "staging", Source: ECSSource, AWSConfigSourceType: SharedCfgProfileType, AWSConfigSource: "staging", AWSRegion: "eu-central-1", ClusterName: "1brd-staging-2", ServiceName: "service-b-fargate", ContainerName: "service-b-main"},
+	}
+
+	if len(got.Versions) != len(expected) {
+		t.Fatalf("got %d version configs, expected %d", len(got.Versions), len(expected))
+	}
+	for i := range expected {
+		if got.Versions[i] != expected[i] {
+			t.Errorf("got: %+v, expected: %+v", got.Versions[i], expected[i])
+		}
+	}
+}
+
+func TestParseFailsForUnknownPlaceholders(t *testing.T) {
+	configStr := `
+env-sequence: ["qa"]
+systems:
+  - key: service-a
+    envs:
+      - name: qa
+        aws-config-source: default
+        service: "{{.Service}}-fargate"
+`
+	var ecsvConfig ECSVConfig
+	if err := yaml.Unmarshal([]byte(configStr), &ecsvConfig); err != nil {
+		t.Fatalf("couldn't unmarshal config: %s", err.Error())
+	}
+
+	_, errs := ecsvConfig.Parse(Filters{})
+
+	if len(errs) == 0 {
+		t.Errorf("expected an error")
+	}
+}
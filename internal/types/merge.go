@@ -0,0 +1,58 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+var (
+	errEnvSequenceConflict    = errors.New("env-sequence is set differently in more than one file")
+	errVersionPatternConflict = errors.New("version-pattern is set differently in more than one file")
+	errEnvDefaultsConflict    = errors.New("defaults for env are set in more than one file")
+)
+
+// Merge combines two configs, eg. when a config file includes another one.
+// Systems, policies, and concurrency limits are concatenated, while top level
+// settings like env-sequence may only be set once (or identically).
+func (c ECSVConfig) Merge(other ECSVConfig) (ECSVConfig, error) {
+	merged := c
+
+	switch {
+	case len(other.EnvSequence) == 0:
+	case len(merged.EnvSequence) == 0:
+		merged.EnvSequence = other.EnvSequence
+	case !slices.Equal(merged.EnvSequence, other.EnvSequence):
+		return merged, fmt.Errorf("%w: %v vs %v", errEnvSequenceConflict, merged.EnvSequence, other.EnvSequence)
+	}
+
+	switch {
+	case other.VersionPattern == nil:
+	case merged.VersionPattern == nil:
+		merged.VersionPattern = other.VersionPattern
+	case *merged.VersionPattern != *other.VersionPattern:
+		return merged, fmt.Errorf("%w: %q vs %q", errVersionPatternConflict, *merged.VersionPattern, *other.VersionPattern)
+	}
+
+	if len(other.Envs) > 0 {
+		envs := make(map[string]envDefaultsConfig, len(merged.Envs)+len(other.Envs))
+		for name, defaults := range merged.Envs {
+			envs[name] = defaults
+		}
+		for name, defaults := range other.Envs {
+			if _, ok := envs[name]; ok {
+				return merged, fmt.Errorf("%w: %s", errEnvDefaultsConflict, name)
+			}
+			envs[name] = defaults
+		}
+		merged.Envs = envs
+	}
+
+	merged.Systems = append(slices.Clip(merged.Systems), other.Systems...)
+	merged.Policies = append(slices.Clip(merged.Policies), other.Policies...)
+	merged.Concurrency.AWS = append(slices.Clip(merged.Concurrency.AWS), other.Concurrency.AWS...)
+	merged.Concurrency.GitHub = append(slices.Clip(merged.Concurrency.GitHub), other.Concurrency.GitHub...)
+	merged.Include = nil
+
+	return merged, nil
+}
@@ -0,0 +1,259 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	errPolicyConfigIsIncorrect     = errors.New("policy config is incorrect")
+	errPolicyNameIsEmpty           = errors.New("name is empty")
+	errPolicyRuleIsInvalid         = errors.New("invalid rule provided")
+	errPolicySystemsPatternInvalid = errors.New("systems pattern is not valid regex")
+	errPolicyEnvNotInEnvSequence   = errors.New("env not present in env-sequence")
+	errPolicyUpstreamMissing       = errors.New("upstream is empty")
+	errPolicyDownstreamMissing     = errors.New("downstream is empty")
+	errPolicyUpstreamNotBefore     = errors.New("upstream needs to precede downstream in env-sequence")
+	errPolicyMaxLagInvalid         = errors.New("max-lag is invalid")
+	errPolicyPatternMissing        = errors.New("pattern is empty")
+	errPolicyPatternInvalid        = errors.New("pattern is not valid regex")
+	errPolicyEnvsMissing           = errors.New("envs is empty")
+	errDurationIsInvalid           = errors.New("duration is invalid")
+)
+
+type PolicyRule uint
+
+const (
+	MaxLagRule PolicyRule = iota
+	NoDownstreamAheadRule
+	InSyncRule
+	ForbiddenVersionRule
+)
+
+var policyRules = map[string]PolicyRule{
+	"max-lag":             MaxLagRule,
+	"no-downstream-ahead": NoDownstreamAheadRule,
+	"in-sync":             InSyncRule,
+	"forbidden-version":   ForbiddenVersionRule,
+}
+
+func PolicyRules() []string {
+	return []string{"max-lag", "no-downstream-ahead", "in-sync", "forbidden-version"}
+}
+
+func (r PolicyRule) String() string {
+	var value string
+	switch r {
+	case MaxLagRule:
+		value = "max-lag"
+	case NoDownstreamAheadRule:
+		value = "no-downstream-ahead"
+	case InSyncRule:
+		value = "in-sync"
+	case ForbiddenVersionRule:
+		value = "forbidden-version"
+	}
+
+	return value
+}
+
+type policyConfig struct {
+	Name       string   `yaml:"name" jsonschema:"required" desc:"name of the policy, shown in violations"`
+	Rule       string   `yaml:"rule" jsonschema:"required" desc:"the rule to enforce"`
+	Systems    *string  `yaml:"systems" desc:"regex for the keys of systems the policy applies to"`
+	Tags       []string `yaml:"tags" desc:"only apply the policy to systems with at least one of these tags"`
+	Envs       []string `yaml:"envs" desc:"envs the policy applies to (in-sync, forbidden-version)"`
+	Upstream   string   `yaml:"upstream" desc:"upstream env (max-lag, no-downstream-ahead)"`
+	Downstream string   `yaml:"downstream" desc:"downstream env (max-lag, no-downstream-ahead)"`
+	MaxLag     string   `yaml:"max-lag" desc:"how long upstream and downstream may differ, eg. \"7d\" or \"36h\" (max-lag)"`
+	Pattern    string   `yaml:"pattern" desc:"regex for versions that are not allowed (forbidden-version)"`
+}
+
+// Policy is a rule that the versions running across envs are expected to
+// follow.
+//
+//   - max-lag: upstream and downstream may run different versions for at most
+//     MaxLag, measured from when the more recent of the two was deployed
+//   - no-downstream-ahead: downstream must never run a newer version than
+//     upstream
+//   - in-sync: all of Envs (or all envs, if empty) must run the same version
+//   - forbidden-version: none of Envs may run a version matching Pattern
+type Policy struct {
+	Name       string
+	Rule       PolicyRule
+	Systems    *regexp.Regexp
+	Tags       []string
+	Envs       []string
+	Upstream   string
+	Downstream string
+	MaxLag     time.Duration
+	Pattern    *regexp.Regexp
+}
+
+// AppliesTo reports whether the policy needs to be evaluated for a system. A
+// policy with tags only applies to systems that have at least one of them.
+func (p Policy) AppliesTo(systemKey string, tags []string) bool {
+	if p.Systems != nil && !p.Systems.MatchString(systemKey) {
+		return false
+	}
+
+	if len(p.Tags) == 0 {
+		return true
+	}
+
+	for _, tag := range tags {
+		if slices.Contains(p.Tags, tag) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// PolicyConfigError holds all the problems found with a single entry under
+// policies. Index is zero based.
+type PolicyConfigError struct {
+	Index int
+	Errs  []error
+}
+
+func (e PolicyConfigError) Error() string {
+	return fmt.Sprintf("%s; index: %d, errors: %v", errPolicyConfigIsIncorrect.Error(), e.Index+1, e.Errs)
+}
+
+func (e PolicyConfigError) Unwrap() error {
+	return errPolicyConfigIsIncorrect
+}
+
+func (c ECSVConfig) parsePolicies() ([]Policy, []error) {
+	var policies []Policy
+	var errors []error
+
+	for i, pc := range c.Policies {
+		var policyErrors []error
+
+		if strings.TrimSpace(pc.Name) == "" {
+			policyErrors = append(policyErrors, errPolicyNameIsEmpty)
+		}
+
+		rule, ok := policyRules[pc.Rule]
+		if !ok {
+			policyErrors = append(policyErrors, fmt.Errorf("%w: %q; possible values: %v", errPolicyRuleIsInvalid, pc.Rule, PolicyRules()))
+		}
+
+		policy := Policy{
+			Name:       pc.Name,
+			Rule:       rule,
+			Tags:       pc.Tags,
+			Envs:       pc.Envs,
+			Upstream:   pc.Upstream,
+			Downstream: pc.Downstream,
+		}
+
+		if pc.Systems != nil {
+			systems, err := regexp.Compile(*pc.Systems)
+			if err != nil {
+				policyErrors = append(policyErrors, fmt.Errorf("%w: %s", errPolicySystemsPatternInvalid, err.Error()))
+			}
+			policy.Systems = systems
+		}
+
+		for _, env := range pc.Envs {
+			if !slices.Contains(c.EnvSequence, env) {
+				policyErrors = append(policyErrors, fmt.Errorf("%w: %s", errPolicyEnvNotInEnvSequence, env))
+			}
+		}
+
+		switch {
+		case !ok:
+			break
+		case rule == MaxLagRule || rule == NoDownstreamAheadRule:
+			policyErrors = append(policyErrors, c.validateUpstreamDownstream(pc)...)
+			if rule == MaxLagRule {
+				maxLag, err := ParseDuration(pc.MaxLag)
+				if err != nil {
+					policyErrors = append(policyErrors, fmt.Errorf("%w: %s", errPolicyMaxLagInvalid, err.Error()))
+				}
+				policy.MaxLag = maxLag
+			}
+		case rule == ForbiddenVersionRule:
+			if len(pc.Envs) == 0 {
+				policyErrors = append(policyErrors, errPolicyEnvsMissing)
+			}
+			if pc.Pattern == "" {
+				policyErrors = append(policyErrors, errPolicyPatternMissing)
+			} else {
+				pattern, err := regexp.Compile(pc.Pattern)
+				if err != nil {
+					policyErrors = append(policyErrors, fmt.Errorf("%w: %s", errPolicyPatternInvalid, err.Error()))
+				}
+				policy.Pattern = pattern
+			}
+		}
+
+		if len(policyErrors) > 0 {
+			errors = append(errors, PolicyConfigError{Index: i, Errs: policyErrors})
+			continue
+		}
+
+		policies = append(policies, policy)
+	}
+
+	return policies, errors
+}
+
+func (c ECSVConfig) validateUpstreamDownstream(pc policyConfig) []error {
+	var errors []error
+
+	if pc.Upstream == "" {
+		errors = append(errors, errPolicyUpstreamMissing)
+	}
+	if pc.Downstream == "" {
+		errors = append(errors, errPolicyDownstreamMissing)
+	}
+	if len(errors) > 0 {
+		return errors
+	}
+
+	upstreamIndex := slices.Index(c.EnvSequence, pc.Upstream)
+	downstreamIndex := slices.Index(c.EnvSequence, pc.Downstream)
+
+	if upstreamIndex == -1 {
+		errors = append(errors, fmt.Errorf("%w: %s", errPolicyEnvNotInEnvSequence, pc.Upstream))
+	}
+	if downstreamIndex == -1 {
+		errors = append(errors, fmt.Errorf("%w: %s", errPolicyEnvNotInEnvSequence, pc.Downstream))
+	}
+	if upstreamIndex != -1 && downstreamIndex != -1 && upstreamIndex >= downstreamIndex {
+		errors = append(errors, errPolicyUpstreamNotBefore)
+	}
+
+	return errors
+}
+
+// ParseDuration works like time.ParseDuration, but also supports days via the
+// "d" suffix (eg. "7d").
+func ParseDuration(value string) (time.Duration, error) {
+	if days, found := strings.CutSuffix(value, "d"); found {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("%w: %q is not a valid number of days", errDurationIsInvalid, value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", errDurationIsInvalid, err.Error())
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("%w: %q is negative", errDurationIsInvalid, value)
+	}
+
+	return d, nil
+}
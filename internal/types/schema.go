@@ -0,0 +1,117 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+const schemaID = "https://raw.githubusercontent.com/dhth/ecsv/main/ecsv.schema.json"
+
+// jsonSchema is the subset of JSON Schema (draft 2020-12) needed to describe
+// ecsv's config.
+type jsonSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	ID                   string                 `json:"$id,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Enum                 []string               `json:"enum,omitempty"`
+	Properties           map[string]*jsonSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties any                    `json:"additionalProperties,omitempty"`
+	Items                *jsonSchema            `json:"items,omitempty"`
+	AnyOf                []*jsonSchema          `json:"anyOf,omitempty"`
+}
+
+// ConfigSchema returns a JSON Schema for ecsv's config file. It's generated
+// from ECSVConfig (using the yaml, desc, and jsonschema struct tags), so it
+// stays in sync with the fields ecsv understands.
+func ConfigSchema() ([]byte, error) {
+	schema := schemaFor(reflect.TypeOf(ECSVConfig{}))
+	schema.Schema = "https://json-schema.org/draft/2020-12/schema"
+	schema.ID = schemaID
+	schema.Title = "ecsv config"
+
+	schema.Properties["policies"].Items.Properties["rule"].Enum = PolicyRules()
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(schema); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func schemaFor(t reflect.Type) *jsonSchema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		if t == reflect.TypeOf(SourceKind("")) {
+			return &jsonSchema{Type: "string", Enum: SourceKinds()}
+		}
+		return &jsonSchema{Type: "string"}
+	case reflect.Bool:
+		return &jsonSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &jsonSchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &jsonSchema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &jsonSchema{Type: "array", Items: schemaFor(t.Elem())}
+	case reflect.Map:
+		return &jsonSchema{Type: "object", AdditionalProperties: schemaFor(t.Elem())}
+	case reflect.Struct:
+		schema := &jsonSchema{
+			Type:                 "object",
+			Properties:           make(map[string]*jsonSchema),
+			AdditionalProperties: false,
+		}
+		addStructFields(schema, t)
+
+		// envs under a system can also just be the env's name (see
+		// envConfig.UnmarshalYAML)
+		if t == reflect.TypeOf(envConfig{}) {
+			return &jsonSchema{
+				AnyOf: []*jsonSchema{
+					{Type: "string", Description: "name of an env, whose values all come from its defaults"},
+					schema,
+				},
+			}
+		}
+
+		return schema
+	}
+
+	return &jsonSchema{}
+}
+
+func addStructFields(schema *jsonSchema, t reflect.Type) {
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name, opts, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if strings.Contains(opts, "inline") {
+			addStructFields(schema, f.Type)
+			continue
+		}
+		if name == "" || name == "-" {
+			continue
+		}
+
+		property := schemaFor(f.Type)
+		property.Description = f.Tag.Get("desc")
+		schema.Properties[name] = property
+
+		if f.Tag.Get("jsonschema") == "required" {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package types
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestCommittedSchemaIsUpToDate(t *testing.T) {
+	committed, err := os.ReadFile("../../ecsv.schema.json")
+	if err != nil {
+		t.Fatalf("couldn't read committed schema: %s", err.Error())
+	}
+
+	got, err := ConfigSchema()
+	if err != nil {
+		t.Fatalf("couldn't generate schema: %s", err.Error())
+	}
+
+	if !bytes.Equal(got, committed) {
+		t.Errorf("ecsv.schema.json is out of date; regenerate it via `go run . config schema > ecsv.schema.json`")
+	}
+}
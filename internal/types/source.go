@@ -0,0 +1,253 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// defaultNamespace is the namespace of Kubernetes deployments that don't have
+// one set
+const defaultNamespace = "default"
+
+var (
+	errSourceIsInvalid         = errors.New("invalid source provided")
+	errVersionFromIsInvalid    = errors.New("invalid version-from provided")
+	errVersionFromNotSupported = errors.New("version-from is not supported for source")
+	errFunctionMissing         = errors.New("function is empty")
+	errDeploymentMissing       = errors.New("deployment is empty")
+	errParameterMissing        = errors.New("parameter is empty")
+	errStackMissing            = errors.New("stack is empty")
+	errStackOutputMissing      = errors.New("output is empty")
+	errURLIsInvalid            = errors.New("url is invalid")
+	errTimeoutIsInvalid        = errors.New("timeout is invalid")
+)
+
+// SourceKind identifies where the version of a system in an env comes from.
+type SourceKind string
+
+const (
+	ECSSource        SourceKind = "ecs"
+	LambdaSource     SourceKind = "lambda"
+	KubernetesSource SourceKind = "kubernetes"
+	HTTPSource       SourceKind = "http"
+	SSMSource        SourceKind = "ssm"
+	CFNSource        SourceKind = "cloudformation"
+)
+
+func SourceKinds() []string {
+	return []string{
+		string(ECSSource),
+		string(LambdaSource),
+		string(KubernetesSource),
+		string(HTTPSource),
+		string(SSMSource),
+		string(CFNSource),
+	}
+}
+
+// IsAWS reports whether versions from the source are fetched using AWS
+// credentials.
+func (k SourceKind) IsAWS() bool {
+	return k == ECSSource || k == LambdaSource || k == SSMSource || k == CFNSource
+}
+
+func parseSourceKind(value SourceKind) (SourceKind, error) {
+	if value == "" {
+		return ECSSource, nil
+	}
+
+	if !slices.Contains(SourceKinds(), string(value)) {
+		return value, fmt.Errorf("%w: %q; possible values: %q", errSourceIsInvalid, value, SourceKinds())
+	}
+
+	return value, nil
+}
+
+type VersionFromKind uint
+
+const (
+	ImageTagVersion VersionFromKind = iota
+	ResourceTagVersion
+	EnvVarVersion
+	ResponseBodyVersion
+	JSONPathVersion
+	RegexVersion
+	ValueVersion
+	DockerLabelVersion
+)
+
+// VersionFrom determines which value of a system's deployment is its version,
+// eg. the tag of a container's image.
+type VersionFrom struct {
+	Kind VersionFromKind
+	// Name is the name of the tag, env var, or docker label holding the
+	// version, or the JSONPath/regex that extracts it from a response body
+	Name string
+}
+
+func (v VersionFrom) String() string {
+	switch v.Kind {
+	case ResourceTagVersion:
+		return "tag:" + v.Name
+	case EnvVarVersion:
+		return "env:" + v.Name
+	case ResponseBodyVersion:
+		return "body"
+	case JSONPathVersion:
+		return "json:" + v.Name
+	case RegexVersion:
+		return "regex:" + v.Name
+	case ValueVersion:
+		return "value"
+	case DockerLabelVersion:
+		return "label:" + v.Name
+	default:
+		return "image"
+	}
+}
+
+var supportedVersionFromKinds = map[SourceKind][]VersionFromKind{
+	ECSSource:        {ImageTagVersion, EnvVarVersion, DockerLabelVersion},
+	LambdaSource:     {ImageTagVersion, ResourceTagVersion, EnvVarVersion},
+	KubernetesSource: {ImageTagVersion},
+	HTTPSource:       {ResponseBodyVersion, JSONPathVersion, RegexVersion},
+	SSMSource:        {ValueVersion},
+	CFNSource:        {ValueVersion},
+}
+
+// defaultVersionFromKinds holds where the version comes from for sources where
+// it's not the image tag, unless version-from is set.
+var defaultVersionFromKinds = map[SourceKind]VersionFromKind{
+	HTTPSource: ResponseBodyVersion,
+	SSMSource:  ValueVersion,
+	CFNSource:  ValueVersion,
+}
+
+func parseVersionFrom(value string, source SourceKind) (VersionFrom, error) {
+	var versionFrom VersionFrom
+	switch {
+	case value == "":
+		versionFrom.Kind = defaultVersionFromKinds[source]
+	case value == "image":
+		versionFrom.Kind = ImageTagVersion
+	case value == "body":
+		versionFrom.Kind = ResponseBodyVersion
+	case value == "value":
+		versionFrom.Kind = ValueVersion
+	case strings.HasPrefix(value, "tag:"):
+		versionFrom = VersionFrom{Kind: ResourceTagVersion, Name: strings.TrimPrefix(value, "tag:")}
+	case strings.HasPrefix(value, "env:"):
+		versionFrom = VersionFrom{Kind: EnvVarVersion, Name: strings.TrimPrefix(value, "env:")}
+	case strings.HasPrefix(value, "label:"):
+		versionFrom = VersionFrom{Kind: DockerLabelVersion, Name: strings.TrimPrefix(value, "label:")}
+	case strings.HasPrefix(value, "json:"):
+		versionFrom = VersionFrom{Kind: JSONPathVersion, Name: strings.TrimPrefix(value, "json:")}
+	case strings.HasPrefix(value, "regex:"):
+		versionFrom = VersionFrom{Kind: RegexVersion, Name: strings.TrimPrefix(value, "regex:")}
+	default:
+		return versionFrom, fmt.Errorf("%w: %q", errVersionFromIsInvalid, value)
+	}
+
+	needsName := versionFrom.Kind != ImageTagVersion && versionFrom.Kind != ResponseBodyVersion && versionFrom.Kind != ValueVersion
+	if needsName && versionFrom.Name == "" {
+		return versionFrom, fmt.Errorf("%w: %q needs a name", errVersionFromIsInvalid, value)
+	}
+
+	if versionFrom.Kind == RegexVersion {
+		if _, err := regexp.Compile(versionFrom.Name); err != nil {
+			return versionFrom, fmt.Errorf("%w: %q: %s", errVersionFromIsInvalid, value, err.Error())
+		}
+	}
+
+	if !slices.Contains(supportedVersionFromKinds[source], versionFrom.Kind) {
+		return versionFrom, fmt.Errorf("%w: %q (source: %s)", errVersionFromNotSupported, value, source)
+	}
+
+	return versionFrom, nil
+}
+
+// SourceKey identifies the credentials/endpoint that fetching a config entry's
+// version goes through, eg. for limiting concurrent fetches per AWS account.
+func (vc VersionsConfig) SourceKey() string {
+	switch vc.Source {
+	case KubernetesSource:
+		return string(vc.Source) + "|" + vc.KubeContext
+	case HTTPSource:
+		return string(vc.Source) + "|" + vc.HTTP.Host()
+	}
+
+	return string(vc.Source) + "|" + vc.AWSConfigKey()
+}
+
+// SourceDescription describes where a config entry's version is fetched from,
+// eg. "profile qa (eu-central-1)" for ECS.
+func (vc VersionsConfig) SourceDescription() string {
+	switch vc.Source {
+	case ECSSource:
+		return vc.AWSConfigDescription()
+	case KubernetesSource:
+		if vc.KubeContext == "" {
+			return "kubernetes: current context"
+		}
+		return fmt.Sprintf("kubernetes: context %s", vc.KubeContext)
+	case HTTPSource:
+		return fmt.Sprintf("http: %s", vc.HTTP.Host())
+	default:
+		return fmt.Sprintf("%s: %s", vc.Source, vc.AWSConfigDescription())
+	}
+}
+
+// defaultHTTPTimeout is the timeout of requests to version endpoints that
+// don't have one set
+const defaultHTTPTimeout = 10 * time.Second
+
+// HTTPEndpoint is a URL that responds with the version of a system.
+type HTTPEndpoint struct {
+	URL     string
+	Headers map[string]string
+	Timeout time.Duration
+}
+
+// Host returns the host (and port, if any) of the endpoint's URL.
+func (e *HTTPEndpoint) Host() string {
+	if e == nil {
+		return ""
+	}
+
+	u, err := url.Parse(e.URL)
+	if err != nil {
+		return e.URL
+	}
+
+	return u.Host
+}
+
+func parseHTTPEndpoint(env envConfig) (*HTTPEndpoint, []error) {
+	var errs []error
+
+	u, err := url.Parse(env.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs = append(errs, fmt.Errorf("%w (env: %s): %q", errURLIsInvalid, env.Name, env.URL))
+	}
+
+	timeout := defaultHTTPTimeout
+	if env.Timeout != "" {
+		timeout, err = time.ParseDuration(env.Timeout)
+		if err != nil || timeout <= 0 {
+			errs = append(errs, fmt.Errorf("%w (env: %s): %q", errTimeoutIsInvalid, env.Name, env.Timeout))
+		}
+	}
+
+	headers := make(map[string]string, len(env.Headers))
+	for name, value := range env.Headers {
+		headers[name] = os.ExpandEnv(value)
+	}
+
+	return &HTTPEndpoint{URL: env.URL, Headers: headers, Timeout: timeout}, errs
+}
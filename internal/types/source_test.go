@@ -0,0 +1,250 @@
+package types
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestParseVersionFrom(t *testing.T) {
+	testCases := []struct {
+		name     string
+		value    string
+		source   SourceKind
+		expected VersionFrom
+		err      error
+	}{
+		{
+			name:     "defaults to image",
+			source:   ECSSource,
+			expected: VersionFrom{Kind: ImageTagVersion},
+		},
+		{
+			name:     "lambda tag",
+			value:    "tag:version",
+			source:   LambdaSource,
+			expected: VersionFrom{Kind: ResourceTagVersion, Name: "version"},
+		},
+		{
+			name:     "lambda env var",
+			value:    "env:APP_VERSION",
+			source:   LambdaSource,
+			expected: VersionFrom{Kind: EnvVarVersion, Name: "APP_VERSION"},
+		},
+		{
+			name:     "ecs docker label",
+			value:    "label:org.opencontainers.image.version",
+			source:   ECSSource,
+			expected: VersionFrom{Kind: DockerLabelVersion, Name: "org.opencontainers.image.version"},
+		},
+		{
+			name:   "docker label unsupported for lambda",
+			value:  "label:version",
+			source: LambdaSource,
+			err:    errVersionFromNotSupported,
+		},
+		{
+			name:     "defaults to response body for http",
+			source:   HTTPSource,
+			expected: VersionFrom{Kind: ResponseBodyVersion},
+		},
+		{
+			name:     "defaults to value for ssm",
+			source:   SSMSource,
+			expected: VersionFrom{Kind: ValueVersion},
+		},
+		{
+			name:   "image tag unsupported for cloudformation",
+			value:  "image",
+			source: CFNSource,
+			err:    errVersionFromNotSupported,
+		},
+		{
+			name:   "invalid regex",
+			value:  "regex:v(\\d+",
+			source: HTTPSource,
+			err:    errVersionFromIsInvalid,
+		},
+		{
+			name:   "missing name",
+			value:  "tag:",
+			source: LambdaSource,
+			err:    errVersionFromIsInvalid,
+		},
+		{
+			name:   "unknown kind",
+			value:  "annotation:version",
+			source: LambdaSource,
+			err:    errVersionFromIsInvalid,
+		},
+		{
+			name:   "unsupported for source",
+			value:  "tag:version",
+			source: ECSSource,
+			err:    errVersionFromNotSupported,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseVersionFrom(tt.value, tt.source)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("expected error %q, got %v", tt.err, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != tt.expected {
+				t.Errorf("got: %+v, expected: %+v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseKubernetesSource(t *testing.T) {
+	configStr := `
+env-sequence: ["qa", "staging"]
+envs:
+  qa:
+    source: kubernetes
+    kube-context: eks-qa
+    deployment: "{{.Key}}"
+systems:
+  - key: service-a
+    envs:
+      - qa
+      - name: staging
+        source: kubernetes
+        namespace: payments
+`
+	var ecsvConfig ECSVConfig
+	if err := yaml.Unmarshal([]byte(configStr), &ecsvConfig); err != nil {
+		t.Fatalf("couldn't unmarshal config: %s", err.Error())
+	}
+
+	_, errs := ecsvConfig.Parse(Filters{})
+	if len(errs) != 1 || !errors.Is(errs[0], errSystemConfigIsIncorrect) {
+		t.Fatalf("expected a single system config error, got: %v", errs)
+	}
+
+	var systemErr SystemConfigError
+	if !errors.As(errs[0], &systemErr) || len(systemErr.Errs) != 1 || !errors.Is(systemErr.Errs[0], errDeploymentMissing) {
+		t.Fatalf("expected deployment to be reported as missing, got: %v", errs[0])
+	}
+
+	ecsvConfig.Systems[0].Envs = ecsvConfig.Systems[0].Envs[:1]
+	got, errs := ecsvConfig.Parse(Filters{})
+	if len(errs) > 0 {
+		t.Fatalf("got unexpected errors: %v", errs)
+	}
+
+	expected := VersionsConfig{Key: "service-a", Env: "qa", Source: KubernetesSource, KubeContext: "eks-qa", Namespace: "default", DeploymentName: "service-a"}
+	if len(got.Versions) != 1 || got.Versions[0] != expected {
+		t.Errorf("got: %+v, expected: %+v", got.Versions, expected)
+	}
+}
+
+func TestParseHTTPSource(t *testing.T) {
+	t.Setenv("VERSION_TOKEN", "secret")
+
+	configStr := `
+env-sequence: ["qa", "staging"]
+envs:
+  qa:
+    source: http
+    url: "https://{{.Key}}.qa.example.com/actuator/info"
+    headers:
+      Authorization: "Bearer ${VERSION_TOKEN}"
+    version-from: json:$.build.version
+systems:
+  - key: service-a
+    envs:
+      - name: qa
+        headers:
+          X-Env: "{{.Env}}"
+        timeout: 3s
+      - name: staging
+        source: http
+        url: staging.example.com/version
+        timeout: soon
+`
+	var ecsvConfig ECSVConfig
+	if err := yaml.Unmarshal([]byte(configStr), &ecsvConfig); err != nil {
+		t.Fatalf("couldn't unmarshal config: %s", err.Error())
+	}
+
+	_, errs := ecsvConfig.Parse(Filters{})
+	var systemErr SystemConfigError
+	if len(errs) != 1 || !errors.As(errs[0], &systemErr) || len(systemErr.Errs) != 2 ||
+		!errors.Is(systemErr.Errs[0], errURLIsInvalid) || !errors.Is(systemErr.Errs[1], errTimeoutIsInvalid) {
+		t.Fatalf("expected url and timeout to be reported as invalid, got: %v", errs)
+	}
+
+	ecsvConfig.Systems[0].Envs = ecsvConfig.Systems[0].Envs[:1]
+	got, errs := ecsvConfig.Parse(Filters{})
+	if len(errs) > 0 {
+		t.Fatalf("got unexpected errors: %v", errs)
+	}
+
+	if len(got.Versions) != 1 || got.Versions[0].HTTP == nil {
+		t.Fatalf("expected a single http config entry, got: %+v", got.Versions)
+	}
+
+	endpoint := got.Versions[0].HTTP
+	if endpoint.URL != "https://service-a.qa.example.com/actuator/info" {
+		t.Errorf("got url %q", endpoint.URL)
+	}
+	if endpoint.Timeout != 3*time.Second {
+		t.Errorf("got timeout %s, expected 3s", endpoint.Timeout)
+	}
+	if endpoint.Headers["Authorization"] != "Bearer secret" || endpoint.Headers["X-Env"] != "qa" {
+		t.Errorf("got unexpected headers: %v", endpoint.Headers)
+	}
+	if got.Versions[0].VersionFrom != (VersionFrom{Kind: JSONPathVersion, Name: "$.build.version"}) {
+		t.Errorf("got unexpected version-from: %v", got.Versions[0].VersionFrom)
+	}
+}
+
+func TestParseReportsMissingSSMAndCloudFormationFields(t *testing.T) {
+	configStr := `
+env-sequence: ["qa", "staging"]
+systems:
+  - key: service-a
+    envs:
+      - name: qa
+        source: ssm
+        aws-config-source: profile:::qa
+        aws-region: eu-central-1
+      - name: staging
+        source: cloudformation
+        aws-config-source: profile:::staging
+        aws-region: eu-central-1
+`
+	var ecsvConfig ECSVConfig
+	if err := yaml.Unmarshal([]byte(configStr), &ecsvConfig); err != nil {
+		t.Fatalf("couldn't unmarshal config: %s", err.Error())
+	}
+
+	_, errs := ecsvConfig.Parse(Filters{})
+	var systemErr SystemConfigError
+	if len(errs) != 1 || !errors.As(errs[0], &systemErr) {
+		t.Fatalf("expected a single system config error, got: %v", errs)
+	}
+
+	expected := []error{errParameterMissing, errStackMissing, errStackOutputMissing}
+	if len(systemErr.Errs) != len(expected) {
+		t.Fatalf("got %d errors, expected %d: %v", len(systemErr.Errs), len(expected), systemErr.Errs)
+	}
+	for i, err := range expected {
+		if !errors.Is(systemErr.Errs[i], err) {
+			t.Errorf("expected error %q, got %v", err, systemErr.Errs[i])
+		}
+	}
+}
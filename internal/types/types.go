@@ -19,6 +19,8 @@ var (
 	errChangesHeadNotInEnvs          = errors.New("head (under changes) is not in the provided envs")
 	errChangesIgnorePatternIncorrect = errors.New("ignore pattern (under changes) is not valid regex")
 	errSystemConfigIsIncorrect       = errors.New("system config is incorrect")
+	errVersionPatternIncorrect       = errors.New("version-pattern is not valid regex")
+	errVersionPatternHasNoGroups     = errors.New("version-pattern needs at least one capture group")
 )
 
 type OutputFmt uint
@@ -27,10 +29,11 @@ const (
 	DefaultFmt OutputFmt = iota
 	TabularFmt
 	HTMLFmt
+	JUnitFmt
 )
 
 func OutputFormats() []string {
-	return []string{"default", "table", "html"}
+	return []string{"default", "table", "html", "junit"}
 }
 
 func (f OutputFmt) String() string {
@@ -42,6 +45,8 @@ func (f OutputFmt) String() string {
 		value = "html"
 	case TabularFmt:
 		value = "table"
+	case JUnitFmt:
+		value = "junit"
 	}
 
 	return value
@@ -56,33 +61,36 @@ const (
 )
 
 type changesConfig struct {
-	Owner         string  `yaml:"owner"`
-	Repo          string  `yaml:"repo"`
-	Base          string  `yaml:"base"`
-	Head          string  `yaml:"head"`
-	IgnorePattern *string `yaml:"ignore-pattern"`
-	Transform     *string `yaml:"transform"`
+	Owner         string  `yaml:"owner" jsonschema:"required" desc:"owner of the GitHub repository"`
+	Repo          string  `yaml:"repo" jsonschema:"required" desc:"name of the GitHub repository"`
+	Base          string  `yaml:"base" jsonschema:"required" desc:"env whose version to use as the base of the comparison"`
+	Head          string  `yaml:"head" jsonschema:"required" desc:"env whose version to use as the head of the comparison"`
+	IgnorePattern *string `yaml:"ignore-pattern" desc:"regex for commit messages to leave out"`
+	Transform     *string `yaml:"transform" desc:"template for turning a version into a git ref, eg. \"v{{version}}\""`
 }
 
 type ECSVConfig struct {
-	EnvSequence []string `yaml:"env-sequence"`
-	Systems     []struct {
-		Key  string `yaml:"key"`
-		Envs []struct {
-			Name            string `yaml:"name"`
-			AwsConfigSource string `yaml:"aws-config-source"`
-			AwsRegion       string `yaml:"aws-region"`
-			Cluster         string `yaml:"cluster"`
-			Service         string `yaml:"service"`
-			ContainerName   string `yaml:"container-name"`
-		} `yaml:"envs"`
-		ChangesConfig *changesConfig `yaml:"changes"`
-	} `yaml:"systems"`
+	Include        []string                     `yaml:"include" desc:"other config files to load, relative to this one; glob patterns are allowed"`
+	EnvSequence    []string                     `yaml:"env-sequence" desc:"envs in the order that versions are promoted through them"`
+	VersionPattern *string                      `yaml:"version-pattern" desc:"regex (with at least one capture group) for parsing versions that aren't semver"`
+	Envs           map[string]envDefaultsConfig `yaml:"envs" desc:"values that all systems inherit for an env, keyed by env name"`
+	Systems        []struct {
+		Key            string         `yaml:"key" jsonschema:"required" desc:"unique identifier of the system"`
+		Envs           []envConfig    `yaml:"envs" jsonschema:"required" desc:"envs the system runs in"`
+		ChangesConfig  *changesConfig `yaml:"changes" desc:"show commits between the versions running in two envs"`
+		VersionPattern *string        `yaml:"version-pattern" desc:"overrides the top level version-pattern for this system"`
+		Team           string         `yaml:"team" desc:"team that owns the system; output is grouped by team for systems without a group"`
+		Tags           []string       `yaml:"tags" desc:"tags for selecting systems via --tags, and in policies"`
+		Group          string         `yaml:"group" desc:"group the system belongs to (eg. a domain); output is grouped by group, and systems can be selected via --groups"`
+	} `yaml:"systems" desc:"systems whose versions are to be checked"`
+	Policies    []policyConfig    `yaml:"policies" desc:"rules that the versions running across envs are expected to follow"`
+	Concurrency concurrencyConfig `yaml:"concurrency" desc:"limits on concurrent fetches, on top of the global one set via ECSV_MAX_CONCURRENT_FETCHES"`
 }
 
 type VersionsConfig struct {
 	Key                 string
 	Env                 string
+	Source              SourceKind
 	AWSConfigSourceType AWSConfigSourceType
 	AWSConfigSource     string
 	AWSRegion           string
@@ -90,6 +98,17 @@ type VersionsConfig struct {
 	ClusterName         string
 	ServiceName         string
 	ContainerName       string
+	FunctionName        string
+	FunctionAlias       string
+	KubeContext         string
+	Namespace           string
+	DeploymentName      string
+	ParameterName       string
+	StackName           string
+	StackOutput         string
+	// HTTP is only set for the http source
+	HTTP        *HTTPEndpoint
+	VersionFrom VersionFrom
 }
 
 type ChangesConfig struct {
@@ -102,54 +121,200 @@ type ChangesConfig struct {
 	Transform     *string
 }
 
+// Filters determines which systems and envs from the config file are to be
+// checked. A nil pattern doesn't filter anything out. If Tags is not empty,
+// only systems that have at least one of them are included; the same goes for
+// Groups.
+type Filters struct {
+	KeyFilter  *regexp.Regexp
+	KeyExclude *regexp.Regexp
+	EnvFilter  *regexp.Regexp
+	EnvExclude *regexp.Regexp
+	Tags       []string
+	Groups     []string
+}
+
+func (f Filters) IncludesKey(key string) bool {
+	return includes(key, f.KeyFilter, f.KeyExclude)
+}
+
+func (f Filters) IncludesTags(tags []string) bool {
+	if len(f.Tags) == 0 {
+		return true
+	}
+
+	for _, tag := range tags {
+		if slices.Contains(f.Tags, tag) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (f Filters) IncludesGroup(group string) bool {
+	return len(f.Groups) == 0 || slices.Contains(f.Groups, group)
+}
+
+func (f Filters) IncludesEnv(env string) bool {
+	return includes(env, f.EnvFilter, f.EnvExclude)
+}
+
+func includes(value string, filter, exclude *regexp.Regexp) bool {
+	if filter != nil && !filter.MatchString(value) {
+		return false
+	}
+
+	if exclude != nil && exclude.MatchString(value) {
+		return false
+	}
+
+	return true
+}
+
+type SystemMetadata struct {
+	Team string
+	Tags []string
+	// Group is used for grouping systems in the output; it takes precedence
+	// over Team
+	Group string
+}
+
 type Config struct {
 	Versions []VersionsConfig
 	Changes  []ChangesConfig
+	// VersionPatterns holds the fallback pattern to use for parsing versions
+	// that are not semver, keyed by system key.
+	VersionPatterns map[string]*regexp.Regexp
+	Policies        []Policy
+	Metadata        map[string]SystemMetadata
+	Concurrency     ConcurrencyLimits
 }
 
-func (c ECSVConfig) Parse(keyRegex *regexp.Regexp) (Config, []error) {
+func (c ECSVConfig) Parse(filters Filters) (Config, []error) {
 	var zero Config
 
 	var versionConfigs []VersionsConfig
 	var changesConfigs []ChangesConfig
 	var errors []error
+	versionPatterns := make(map[string]*regexp.Regexp)
+	metadata := make(map[string]SystemMetadata)
+
+	var defaultVersionPattern *regexp.Regexp
+	if c.VersionPattern != nil {
+		vp, err := parseVersionPattern(*c.VersionPattern)
+		if err != nil {
+			errors = append(errors, err)
+		} else {
+			defaultVersionPattern = vp
+		}
+	}
 
 	for i, system := range c.Systems {
 		var systemErrors []error
-		if keyRegex != nil && !keyRegex.Match([]byte(system.Key)) {
+		if !filters.IncludesKey(system.Key) || !filters.IncludesTags(system.Tags) || !filters.IncludesGroup(system.Group) {
 			continue
 		}
 
+		metadata[system.Key] = SystemMetadata{
+			Team:  system.Team,
+			Tags:  system.Tags,
+			Group: system.Group,
+		}
+
+		versionPatterns[system.Key] = defaultVersionPattern
+		if system.VersionPattern != nil {
+			vp, err := parseVersionPattern(*system.VersionPattern)
+			if err != nil {
+				systemErrors = append(systemErrors, err)
+			} else {
+				versionPatterns[system.Key] = vp
+			}
+		}
+
 		systemEnvs := make([]string, len(system.Envs))
-		for j, env := range system.Envs {
-			systemEnvs[j] = env.Name
+		for j, systemEnv := range system.Envs {
+			systemEnvs[j] = systemEnv.Name
+			env, err := systemEnv.resolve(c.Envs[systemEnv.Name], system.Key)
+			if err != nil {
+				systemErrors = append(systemErrors, err)
+				continue
+			}
+
+			source, err := parseSourceKind(env.Source)
+			if err != nil {
+				systemErrors = append(systemErrors, fmt.Errorf("%w (env: %s)", err, env.Name))
+			}
+
+			versionFrom, err := parseVersionFrom(env.VersionFrom, source)
+			if err != nil {
+				systemErrors = append(systemErrors, fmt.Errorf("%w (env: %s)", err, env.Name))
+			}
+
+			if source == LambdaSource && strings.TrimSpace(env.Function) == "" {
+				systemErrors = append(systemErrors, fmt.Errorf("%w (env: %s)", errFunctionMissing, env.Name))
+			}
+
+			if source == KubernetesSource && strings.TrimSpace(env.Deployment) == "" {
+				systemErrors = append(systemErrors, fmt.Errorf("%w (env: %s)", errDeploymentMissing, env.Name))
+			}
+
+			if source == SSMSource && strings.TrimSpace(env.Parameter) == "" {
+				systemErrors = append(systemErrors, fmt.Errorf("%w (env: %s)", errParameterMissing, env.Name))
+			}
+
+			if source == CFNSource {
+				if strings.TrimSpace(env.Stack) == "" {
+					systemErrors = append(systemErrors, fmt.Errorf("%w (env: %s)", errStackMissing, env.Name))
+				}
+				if strings.TrimSpace(env.Output) == "" {
+					systemErrors = append(systemErrors, fmt.Errorf("%w (env: %s)", errStackOutputMissing, env.Name))
+				}
+			}
+
+			var httpEndpoint *HTTPEndpoint
+			if source == HTTPSource {
+				var errs []error
+				httpEndpoint, errs = parseHTTPEndpoint(env)
+				systemErrors = append(systemErrors, errs...)
+			}
+
 			var awsConfigType AWSConfigSourceType
 			var awsConfigSource string
-			switch {
-			case env.AwsConfigSource == "default":
-				awsConfigType = DefaultCfgType
-			case strings.HasPrefix(env.AwsConfigSource, "profile:::"):
-				configElements := strings.Split(env.AwsConfigSource, "profile:::")
-				awsConfigSource = os.ExpandEnv(configElements[len(configElements)-1])
-				awsConfigType = SharedCfgProfileType
-			case strings.HasPrefix(env.AwsConfigSource, "assume-role:::"):
-				configElements := strings.Split(env.AwsConfigSource, "assume-role:::")
-				awsConfigSource = os.ExpandEnv(configElements[len(configElements)-1])
-				awsConfigType = AssumeRoleCfgType
-			default:
-				systemErrors = append(systemErrors, errInvalidConfigSourceProvided)
+			if source.IsAWS() {
+				var ok bool
+				awsConfigType, awsConfigSource, ok = parseAWSConfigSource(env.AwsConfigSource)
+				if !ok {
+					systemErrors = append(systemErrors, fmt.Errorf("%w (env: %s): %q", errInvalidConfigSourceProvided, env.Name, env.AwsConfigSource))
+				}
 			}
 
-			if len(systemErrors) == 0 {
+			namespace := env.Namespace
+			if source == KubernetesSource && namespace == "" {
+				namespace = defaultNamespace
+			}
+
+			if len(systemErrors) == 0 && filters.IncludesEnv(env.Name) {
 				versionConfigs = append(versionConfigs, VersionsConfig{
 					Key:                 system.Key,
 					Env:                 env.Name,
+					Source:              source,
 					AWSConfigSourceType: awsConfigType,
 					AWSConfigSource:     awsConfigSource,
 					AWSRegion:           env.AwsRegion,
 					ClusterName:         env.Cluster,
 					ServiceName:         env.Service,
 					ContainerName:       env.ContainerName,
+					FunctionName:        env.Function,
+					FunctionAlias:       env.FunctionAlias,
+					KubeContext:         env.KubeContext,
+					Namespace:           namespace,
+					DeploymentName:      env.Deployment,
+					ParameterName:       env.Parameter,
+					StackName:           env.Stack,
+					StackOutput:         env.Output,
+					HTTP:                httpEndpoint,
+					VersionFrom:         versionFrom,
 				})
 			}
 		}
@@ -181,7 +346,9 @@ func (c ECSVConfig) Parse(keyRegex *regexp.Regexp) (Config, []error) {
 				}
 			}
 
-			if len(systemErrors) == 0 {
+			if len(systemErrors) == 0 &&
+				filters.IncludesEnv(system.ChangesConfig.Base) &&
+				filters.IncludesEnv(system.ChangesConfig.Head) {
 				changesConfigs = append(changesConfigs, ChangesConfig{
 					SystemKey:     system.Key,
 					Owner:         system.ChangesConfig.Owner,
@@ -195,20 +362,76 @@ func (c ECSVConfig) Parse(keyRegex *regexp.Regexp) (Config, []error) {
 		}
 
 		if len(systemErrors) > 0 {
-			errors = append(errors, fmt.Errorf("%w; index: %d, errors: %v", errSystemConfigIsIncorrect, i+1, systemErrors))
+			errors = append(errors, SystemConfigError{Index: i, Key: system.Key, Errs: systemErrors})
 		}
 	}
 
+	policies, policyErrors := c.parsePolicies()
+	errors = append(errors, policyErrors...)
+
+	concurrency, concurrencyErrors := c.Concurrency.parse()
+	errors = append(errors, concurrencyErrors...)
+
 	if len(errors) > 0 {
 		return zero, errors
 	}
 
 	return Config{
-		Versions: versionConfigs,
-		Changes:  changesConfigs,
+		Versions:        versionConfigs,
+		Changes:         changesConfigs,
+		VersionPatterns: versionPatterns,
+		Policies:        policies,
+		Metadata:        metadata,
+		Concurrency:     concurrency,
 	}, nil
 }
 
+// SystemConfigError holds all the problems found with a single entry under
+// systems. Index is zero based.
+type SystemConfigError struct {
+	Index int
+	Key   string
+	Errs  []error
+}
+
+func (e SystemConfigError) Error() string {
+	return fmt.Sprintf("%s; index: %d, errors: %v", errSystemConfigIsIncorrect.Error(), e.Index+1, e.Errs)
+}
+
+func (e SystemConfigError) Unwrap() error {
+	return errSystemConfigIsIncorrect
+}
+
+// parseAWSConfigSource parses the value of aws-config-source, eg.
+// "profile:::qa".
+func parseAWSConfigSource(value string) (AWSConfigSourceType, string, bool) {
+	switch {
+	case value == "default":
+		return DefaultCfgType, "", true
+	case strings.HasPrefix(value, "profile:::"):
+		configElements := strings.Split(value, "profile:::")
+		return SharedCfgProfileType, os.ExpandEnv(configElements[len(configElements)-1]), true
+	case strings.HasPrefix(value, "assume-role:::"):
+		configElements := strings.Split(value, "assume-role:::")
+		return AssumeRoleCfgType, os.ExpandEnv(configElements[len(configElements)-1]), true
+	default:
+		return DefaultCfgType, "", false
+	}
+}
+
+func parseVersionPattern(pattern string) (*regexp.Regexp, error) {
+	vp, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errVersionPatternIncorrect, err.Error())
+	}
+
+	if vp.NumSubexp() == 0 {
+		return nil, fmt.Errorf("%w: %q", errVersionPatternHasNoGroups, pattern)
+	}
+
+	return vp, nil
+}
+
 func (vc VersionsConfig) AWSConfigKey() string {
 	switch vc.AWSConfigSourceType {
 	case SharedCfgProfileType, AssumeRoleCfgType:
@@ -218,13 +441,65 @@ func (vc VersionsConfig) AWSConfigKey() string {
 	}
 }
 
+// AWSConfigDescription describes where the AWS credentials for a config entry
+// come from, eg. "profile qa (eu-central-1)".
+func (vc VersionsConfig) AWSConfigDescription() string {
+	switch vc.AWSConfigSourceType {
+	case SharedCfgProfileType:
+		return fmt.Sprintf("profile %s (%s)", vc.AWSConfigSource, vc.AWSRegion)
+	case AssumeRoleCfgType:
+		return fmt.Sprintf("role %s (%s)", vc.AWSConfigSource, vc.AWSRegion)
+	default:
+		return fmt.Sprintf("default (%s)", vc.AWSRegion)
+	}
+}
+
+// CredentialsError is the error for config entries whose AWS credentials
+// couldn't be set up or used. As it's shared by all entries that use the same
+// credentials, it's meant to be reported once per Source.
+type CredentialsError struct {
+	Source string
+	Hint   string
+	Err    error
+}
+
+func (e CredentialsError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Source, e.Err.Error())
+}
+
+func (e CredentialsError) Unwrap() error {
+	return e.Err
+}
+
 type VersionResult struct {
 	SystemKey    string
 	Env          string
 	Version      string
 	Found        bool
 	RegisteredAt *time.Time
-	Err          error
+	// TaskDefinition is only set for versions fetched from ECS
+	TaskDefinition *TaskDefinition
+	Err            error
+}
+
+// TaskDefinition describes the ECS task definition that a version was found
+// in, along with the container it was read from.
+type TaskDefinition struct {
+	ARN      string
+	Family   string
+	Revision int32
+	// Image is the full URI of the container's image
+	Image string
+	// CPU and Memory are the task's limits if set, and the container's
+	// otherwise; they're empty if neither is set
+	CPU    string
+	Memory string
+}
+
+// FamilyRevision returns the task definition's "family:revision", as ECS
+// refers to it.
+func (td TaskDefinition) FamilyRevision() string {
+	return fmt.Sprintf("%s:%d", td.Family, td.Revision)
 }
 
 type ChangesResult struct {
@@ -275,3 +550,25 @@ func GetStyle(styleStr string) (TableStyle, bool) {
 	style, ok := styles[styleStr]
 	return style, ok
 }
+
+// FetchTiming is a breakdown of how long fetches from a single source (eg. an
+// AWS config, or a GitHub host) took.
+type FetchTiming struct {
+	Source string
+	// Limit is the source's concurrency limit; 0 means that only the global
+	// limit applied
+	Limit   int
+	Fetches int
+	// Waited is the total time fetches spent waiting for a free slot
+	Waited     time.Duration
+	Total      time.Duration
+	Slowest    time.Duration
+	FirstStart time.Time
+	LastEnd    time.Time
+}
+
+// Elapsed is the time between the first fetch starting and the last one
+// finishing.
+func (t FetchTiming) Elapsed() time.Duration {
+	return t.LastEnd.Sub(t.FirstStart)
+}
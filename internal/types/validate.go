@@ -0,0 +1,471 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigDocument is a single config file, both as a YAML node tree (which
+// holds the positions of all values) and as the config it decodes into.
+type ConfigDocument struct {
+	Path   string
+	Root   *yaml.Node
+	Config ECSVConfig
+}
+
+// ConfigIssue is a problem found while validating config files.
+type ConfigIssue struct {
+	File      string
+	Line      int
+	Column    int
+	SystemKey string
+	Message   string
+}
+
+func (i ConfigIssue) String() string {
+	location := i.File
+	if i.Line > 0 {
+		location = fmt.Sprintf("%s:%d:%d", i.File, i.Line, i.Column)
+	}
+
+	if i.SystemKey != "" {
+		return fmt.Sprintf("%s: [%s] %s", location, i.SystemKey, i.Message)
+	}
+
+	return fmt.Sprintf("%s: %s", location, i.Message)
+}
+
+// entryRef points to an entry under systems or policies in a config document.
+type entryRef struct {
+	doc  int
+	node *yaml.Node
+}
+
+type validator struct {
+	docs   []ConfigDocument
+	issues []ConfigIssue
+}
+
+func (v *validator) report(doc int, node *yaml.Node, systemKey, message string) {
+	issue := ConfigIssue{
+		File:      v.docs[doc].Path,
+		SystemKey: systemKey,
+		Message:   message,
+	}
+	if node != nil {
+		issue.Line = node.Line
+		issue.Column = node.Column
+	}
+
+	v.issues = append(v.issues, issue)
+}
+
+// Validate checks config documents (which together form ecsv's config) for
+// problems, and reports all of them along with their location. Unlike Parse,
+// it also flags unknown fields, duplicate system keys, duplicate envs within a
+// system, and envs in env-sequence that no system uses.
+func Validate(docs []ConfigDocument) []ConfigIssue {
+	if len(docs) == 0 {
+		return nil
+	}
+
+	v := validator{docs: docs}
+
+	var systems, policies []entryRef
+	var merged ECSVConfig
+	for i, doc := range docs {
+		root := documentMapping(doc.Root)
+		v.checkUnknownFields(i, root)
+
+		_, systemsNode := mappingEntry(root, "systems")
+		for _, node := range sequenceItems(systemsNode) {
+			systems = append(systems, entryRef{doc: i, node: node})
+		}
+		_, policiesNode := mappingEntry(root, "policies")
+		for _, node := range sequenceItems(policiesNode) {
+			policies = append(policies, entryRef{doc: i, node: node})
+		}
+
+		m, err := merged.Merge(doc.Config)
+		if err != nil {
+			v.report(i, mergeConflictNode(root, err), "", err.Error())
+			m, _ = merged.Merge(withoutConflicts(merged, doc.Config))
+		}
+		merged = m
+	}
+
+	// positions of entries can only be tracked if the documents decoded into
+	// the configs they were provided with
+	if len(systems) != len(merged.Systems) || len(policies) != len(merged.Policies) {
+		systems = nil
+		policies = nil
+	}
+
+	v.checkSystems(merged, systems)
+	v.checkEnvSequence(merged)
+
+	_, errs := merged.Parse(Filters{})
+	for _, err := range errs {
+		var systemErr SystemConfigError
+		var policyErr PolicyConfigError
+		switch {
+		case errors.As(err, &systemErr):
+			doc, node := v.locate(systems, systemErr.Index)
+			for _, e := range systemErr.Errs {
+				v.report(doc, systemKeyNode(node), systemErr.Key, e.Error())
+			}
+		case errors.As(err, &policyErr):
+			doc, node := v.locate(policies, policyErr.Index)
+			for _, e := range policyErr.Errs {
+				v.report(doc, node, "", fmt.Sprintf("policy %q: %s", merged.Policies[policyErr.Index].Name, e.Error()))
+			}
+		case errors.Is(err, errConcurrencyConfigIsIncorrect):
+			doc, node := v.locateTopLevel("concurrency")
+			v.report(doc, node, "", err.Error())
+		default:
+			doc, node := v.locateTopLevel("version-pattern")
+			v.report(doc, node, "", err.Error())
+		}
+	}
+
+	slices.SortStableFunc(v.issues, func(a, b ConfigIssue) int {
+		if a.File != b.File {
+			return v.docIndex(a.File) - v.docIndex(b.File)
+		}
+		if a.Line != b.Line {
+			return a.Line - b.Line
+		}
+		return a.Column - b.Column
+	})
+
+	return v.issues
+}
+
+func (v *validator) checkSystems(config ECSVConfig, refs []entryRef) {
+	firstSeen := make(map[string]int)
+
+	for i, system := range config.Systems {
+		doc, node := v.locate(refs, i)
+
+		if strings.TrimSpace(system.Key) == "" {
+			v.report(doc, node, "", "system key is empty")
+		} else if first, ok := firstSeen[system.Key]; ok {
+			firstDoc, firstNode := v.locate(refs, first)
+			v.report(doc, systemKeyNode(node), system.Key, fmt.Sprintf("duplicate system key; first defined at %s", v.position(firstDoc, systemKeyNode(firstNode))))
+		} else {
+			firstSeen[system.Key] = i
+		}
+
+		_, envsNode := mappingEntry(node, "envs")
+		envNodes := sequenceItems(envsNode)
+		seenEnvs := make(map[string]bool)
+		for j, env := range system.Envs {
+			var envNode *yaml.Node
+			if j < len(envNodes) {
+				envNode = envNodes[j]
+			}
+
+			switch {
+			case seenEnvs[env.Name]:
+				v.report(doc, envNode, system.Key, fmt.Sprintf("env %q is listed more than once", env.Name))
+			case !slices.Contains(config.EnvSequence, env.Name):
+				v.report(doc, envNode, system.Key, fmt.Sprintf("env %q is not present in env-sequence", env.Name))
+			}
+			seenEnvs[env.Name] = true
+		}
+	}
+}
+
+func (v *validator) checkEnvSequence(config ECSVConfig) {
+	used := make(map[string]bool)
+	for _, system := range config.Systems {
+		for _, env := range system.Envs {
+			used[env.Name] = true
+		}
+	}
+
+	doc, seqNode := v.locateTopLevel("env-sequence")
+	if len(config.EnvSequence) == 0 {
+		v.report(doc, seqNode, "", "env-sequence is empty")
+		return
+	}
+
+	_, seqValue := mappingEntry(documentMapping(v.docs[doc].Root), "env-sequence")
+	items := sequenceItems(seqValue)
+	for i, env := range config.EnvSequence {
+		if used[env] {
+			continue
+		}
+
+		node := seqNode
+		if i < len(items) {
+			node = items[i]
+		}
+		v.report(doc, node, "", fmt.Sprintf("env %q in env-sequence is not used by any system", env))
+	}
+}
+
+// checkUnknownFields reports fields that ecsv doesn't know about, eg. typos
+// like "contianer-name".
+func (v *validator) checkUnknownFields(doc int, root *yaml.Node) {
+	if root == nil {
+		return
+	}
+
+	topLevel := yamlFields(reflect.TypeOf(ECSVConfig{}))
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		field, ok := topLevel[key.Value]
+		if !ok {
+			v.reportUnknownField(doc, key, "", topLevel)
+			continue
+		}
+
+		if key.Value != "systems" {
+			v.checkNodeFields(doc, value, field, "")
+			continue
+		}
+
+		for _, system := range sequenceItems(value) {
+			_, keyNode := mappingEntry(system, "key")
+			var systemKey string
+			if keyNode != nil {
+				systemKey = keyNode.Value
+			}
+			v.checkNodeFields(doc, system, field.Elem(), systemKey)
+		}
+	}
+}
+
+func (v *validator) checkNodeFields(doc int, node *yaml.Node, t reflect.Type, systemKey string) {
+	if node == nil {
+		return
+	}
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Value == "<<" {
+				continue
+			}
+			field, ok := fields[key.Value]
+			if !ok {
+				v.reportUnknownField(doc, key, systemKey, fields)
+				continue
+			}
+			v.checkNodeFields(doc, value, field, systemKey)
+		}
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+		for i := 1; i < len(node.Content); i += 2 {
+			v.checkNodeFields(doc, node.Content[i], t.Elem(), systemKey)
+		}
+	case reflect.Slice:
+		for _, item := range sequenceItems(node) {
+			v.checkNodeFields(doc, item, t.Elem(), systemKey)
+		}
+	}
+}
+
+func (v *validator) reportUnknownField(doc int, key *yaml.Node, systemKey string, known map[string]reflect.Type) {
+	message := fmt.Sprintf("unknown field %q", key.Value)
+	if suggestion, ok := closestField(key.Value, known); ok {
+		message = fmt.Sprintf("%s; did you mean %q?", message, suggestion)
+	}
+
+	v.report(doc, key, systemKey, message)
+}
+
+func (v *validator) locate(refs []entryRef, index int) (int, *yaml.Node) {
+	if index < len(refs) {
+		return refs[index].doc, refs[index].node
+	}
+
+	return 0, nil
+}
+
+// locateTopLevel returns the first document that sets a top level key, along
+// with the key's node.
+func (v *validator) locateTopLevel(key string) (int, *yaml.Node) {
+	for i, doc := range v.docs {
+		keyNode, _ := mappingEntry(documentMapping(doc.Root), key)
+		if keyNode != nil {
+			return i, keyNode
+		}
+	}
+
+	return 0, nil
+}
+
+func (v *validator) position(doc int, node *yaml.Node) string {
+	if node == nil {
+		return v.docs[doc].Path
+	}
+
+	return fmt.Sprintf("%s:%d:%d", v.docs[doc].Path, node.Line, node.Column)
+}
+
+func (v *validator) docIndex(path string) int {
+	return slices.IndexFunc(v.docs, func(d ConfigDocument) bool {
+		return d.Path == path
+	})
+}
+
+func mergeConflictNode(root *yaml.Node, err error) *yaml.Node {
+	var key string
+	switch {
+	case errors.Is(err, errEnvSequenceConflict):
+		key = "env-sequence"
+	case errors.Is(err, errVersionPatternConflict):
+		key = "version-pattern"
+	default:
+		key = "envs"
+	}
+
+	keyNode, _ := mappingEntry(root, key)
+	return keyNode
+}
+
+// withoutConflicts drops the settings in other that can't be merged into
+// config, so that the rest of it can still be validated.
+func withoutConflicts(config, other ECSVConfig) ECSVConfig {
+	if len(config.EnvSequence) > 0 {
+		other.EnvSequence = nil
+	}
+	if config.VersionPattern != nil {
+		other.VersionPattern = nil
+	}
+
+	envs := make(map[string]envDefaultsConfig, len(other.Envs))
+	for name, defaults := range other.Envs {
+		if _, ok := config.Envs[name]; !ok {
+			envs[name] = defaults
+		}
+	}
+	other.Envs = envs
+
+	return other
+}
+
+func documentMapping(node *yaml.Node) *yaml.Node {
+	if node == nil {
+		return nil
+	}
+	if node.Kind == yaml.DocumentNode {
+		if len(node.Content) == 0 {
+			return nil
+		}
+		node = node.Content[0]
+	}
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	return node
+}
+
+func mappingEntry(node *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i], node.Content[i+1]
+		}
+	}
+
+	return nil, nil
+}
+
+func sequenceItems(node *yaml.Node) []*yaml.Node {
+	if node == nil || node.Kind != yaml.SequenceNode {
+		return nil
+	}
+
+	return node.Content
+}
+
+func systemKeyNode(system *yaml.Node) *yaml.Node {
+	_, keyNode := mappingEntry(system, "key")
+	if keyNode != nil {
+		return keyNode
+	}
+
+	return system
+}
+
+// yamlFields returns the types of a struct's fields, keyed by their YAML
+// name. Fields of inlined structs are included.
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name, opts, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if strings.Contains(opts, "inline") {
+			for k, ft := range yamlFields(f.Type) {
+				fields[k] = ft
+			}
+			continue
+		}
+		if name == "" || name == "-" {
+			continue
+		}
+		fields[name] = f.Type
+	}
+
+	return fields
+}
+
+func closestField(name string, known map[string]reflect.Type) (string, bool) {
+	var closest string
+	best := 3
+	for candidate := range known {
+		d := editDistance(name, candidate)
+		if d < best || d == best && candidate < closest {
+			closest, best = candidate, d
+		}
+	}
+
+	return closest, closest != "" && best < 3
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
@@ -0,0 +1,65 @@
+package types
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestValidate(t *testing.T) {
+	base := `env-sequence: ["qa", "staging", "prod"]
+envs:
+  qa:
+    aws-config-source: profile:::qa
+  staging:
+    aws-config-source: profile:::staging
+systems:
+  - key: service-a
+    envs:
+      - name: qa
+        contianer-name: service-a
+      - qa
+      - dev
+`
+	other := `systems:
+  - key: service-a
+    envs: [staging]
+policies:
+  - name: p
+    rule: unknown
+`
+
+	docs := make([]ConfigDocument, 2)
+	for i, content := range []string{base, other} {
+		var root yaml.Node
+		if err := yaml.Unmarshal([]byte(content), &root); err != nil {
+			t.Fatalf("couldn't unmarshal config: %s", err.Error())
+		}
+		var config ECSVConfig
+		if err := root.Decode(&config); err != nil {
+			t.Fatalf("couldn't decode config: %s", err.Error())
+		}
+		docs[i] = ConfigDocument{Path: []string{"base.yml", "other.yml"}[i], Root: &root, Config: config}
+	}
+
+	got := Validate(docs)
+
+	expected := []string{
+		`base.yml:1:33: env "prod" in env-sequence is not used by any system`,
+		`base.yml:8:10: [service-a] invalid aws-system-source provided (env: dev): ""`,
+		`base.yml:11:9: [service-a] unknown field "contianer-name"; did you mean "container-name"?`,
+		`base.yml:12:9: [service-a] env "qa" is listed more than once`,
+		`base.yml:13:9: [service-a] env "dev" is not present in env-sequence`,
+		`other.yml:2:10: [service-a] duplicate system key; first defined at base.yml:8:10`,
+		`other.yml:5:5: policy "p": invalid rule provided: "unknown"; possible values: [max-lag no-downstream-ahead in-sync forbidden-version]`,
+	}
+
+	if len(got) != len(expected) {
+		t.Fatalf("got %d issues, expected %d; got: %v", len(got), len(expected), got)
+	}
+	for i := range got {
+		if got[i].String() != expected[i] {
+			t.Errorf("got: %s, expected: %s", got[i].String(), expected[i])
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dhth/ecsv/internal/doctor"
+	"github.com/dhth/ecsv/internal/types"
+)
+
+// GetDoctorOutput renders the results of credential checks as a table.
+func GetDoctorOutput(checks []doctor.Check, style types.TableStyle) (string, error) {
+	rows := make([][]string, len(checks))
+	var failed int
+	for i, c := range checks {
+		result := "ok"
+		if !c.OK() {
+			failed++
+			result = c.Err.Error()
+			if c.Hint != "" {
+				result = fmt.Sprintf("%s\n(hint: %s)", result, c.Hint)
+			}
+		}
+
+		rows[i] = []string{
+			c.Credentials,
+			strings.Join(c.UsedBy, ", "),
+			valueOrDash(c.Identity),
+			valueOrDash(c.Access),
+			result,
+		}
+	}
+
+	table, err := renderTable(style, []string{"credentials", "used by", "identity", "access checked", "result"}, rows)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s\n%d/%d working\n", table, len(checks)-failed, len(checks)), nil
+}
+
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+
+	return value
+}
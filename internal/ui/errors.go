@@ -0,0 +1,66 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dhth/ecsv/internal/types"
+)
+
+// ErrorGroup is an error shared by one or more systems/envs, eg. all the
+// systems that use AWS credentials that have expired.
+type ErrorGroup struct {
+	Message  string
+	Hint     string
+	Affected []string
+}
+
+func (g ErrorGroup) String() string {
+	var b strings.Builder
+	b.WriteString(g.Message)
+	if g.Hint != "" {
+		fmt.Fprintf(&b, " (hint: %s)", g.Hint)
+	}
+	fmt.Fprintf(&b, "; affects: %s", strings.Join(g.Affected, ", "))
+
+	return b.String()
+}
+
+// errorGroups collects errors encountered while fetching versions, grouping
+// credential errors by their source, and deduplicating identical messages.
+type errorGroups struct {
+	groups []ErrorGroup
+	index  map[string]int
+}
+
+func newErrorGroups() *errorGroups {
+	return &errorGroups{index: make(map[string]int)}
+}
+
+// add records an error for a system and env, and returns the index of the
+// group it belongs to.
+func (e *errorGroups) add(systemKey, env string, err error) int {
+	group := ErrorGroup{Message: err.Error()}
+	key := group.Message
+
+	var credsErr types.CredentialsError
+	if errors.As(err, &credsErr) {
+		group.Hint = credsErr.Hint
+		// messages for the same problem can differ (eg. by request ID), so
+		// credential errors with a hint are grouped by the hint alone
+		if credsErr.Hint != "" {
+			key = fmt.Sprintf("%s\x00%s", credsErr.Source, credsErr.Hint)
+		}
+	}
+
+	i, ok := e.index[key]
+	if !ok {
+		i = len(e.groups)
+		e.index[key] = i
+		e.groups = append(e.groups, group)
+	}
+	e.groups[i].Affected = append(e.groups[i].Affected, fmt.Sprintf("%s (%s)", systemKey, env))
+
+	return i
+}
@@ -0,0 +1,44 @@
+package ui
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dhth/ecsv/internal/types"
+)
+
+var errServiceNotFound = errors.New("service not found")
+
+func TestErrorGroupsDedupesErrors(t *testing.T) {
+	expired := func(requestID string) error {
+		return types.CredentialsError{
+			Source: "profile qa (eu-central-1)",
+			Hint:   `SSO session has expired; run "aws sso login --profile qa"`,
+			Err:    errors.New("token expired, request id: " + requestID),
+		}
+	}
+
+	groups := newErrorGroups()
+	indexes := []int{
+		groups.add("service-a", "qa", expired("1")),
+		groups.add("service-b", "qa", expired("2")),
+		groups.add("service-a", "staging", errServiceNotFound),
+		groups.add("service-b", "staging", errServiceNotFound),
+	}
+
+	expectedIndexes := []int{0, 0, 1, 1}
+	for i := range indexes {
+		if indexes[i] != expectedIndexes[i] {
+			t.Errorf("got index %d for error %d, expected %d", indexes[i], i, expectedIndexes[i])
+		}
+	}
+
+	if len(groups.groups) != 2 {
+		t.Fatalf("got %d groups, expected 2", len(groups.groups))
+	}
+
+	expected := `profile qa (eu-central-1): token expired, request id: 1 (hint: SSO session has expired; run "aws sso login --profile qa"); affects: service-a (qa), service-b (qa)`
+	if got := groups.groups[0].String(); got != expected {
+		t.Errorf("got: %s, expected: %s", got, expected)
+	}
+}
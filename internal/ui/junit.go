@@ -0,0 +1,168 @@
+package ui
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	junitSuiteName       = "ecsv"
+	junitOutOfSyncType   = "OutOfSync"
+	junitAnomalyType     = "DownstreamAhead"
+	junitViolationType   = "PolicyViolation"
+	junitFetchErrorType  = "FetchError"
+	junitTimestampFormat = "2006-01-02T15:04:05"
+)
+
+var errCouldntRenderJUnit = errors.New("couldn't render JUnit XML")
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Details string `xml:",chardata"`
+}
+
+func getJUnitOutput(config Config, report Report, now time.Time) (string, error) {
+	violations := make(map[string][]string)
+	for _, v := range report.Violations {
+		violations[v.SystemKey] = append(violations[v.SystemKey], fmt.Sprintf("%s: %s", v.Env, v.String()))
+	}
+
+	testSuites := junitTestSuites{
+		Name: junitSuiteName,
+	}
+
+	for _, group := range config.groups() {
+		suite := getJUnitTestSuite(group, config.EnvSequence, report, violations, now)
+		testSuites.Tests += suite.Tests
+		testSuites.Failures += suite.Failures
+		testSuites.Errors += suite.Errors
+		testSuites.Suites = append(testSuites.Suites, suite)
+	}
+
+	out, err := xml.MarshalIndent(testSuites, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", errCouldntRenderJUnit, err.Error())
+	}
+
+	return xml.Header + string(out) + "\n", nil
+}
+
+func getJUnitTestSuite(group SystemGroup,
+	envSequence []string,
+	report Report,
+	violations map[string][]string,
+	now time.Time,
+) junitTestSuite {
+	results := report.Versions
+	suiteName := junitSuiteName
+	if group.Name != "" {
+		suiteName = group.Name
+	}
+
+	suite := junitTestSuite{
+		Name:      suiteName,
+		Timestamp: now.Format(junitTimestampFormat),
+		TestCases: make([]junitTestCase, 0, len(group.SystemKeys)),
+	}
+
+	for _, sys := range group.SystemKeys {
+		var versions []versionInfo
+		var details []string
+		var fetchErrors []string
+		for _, env := range envSequence {
+			r, ok := results[sys][env]
+			if !ok {
+				versions = append(versions, versionInfo{})
+				continue
+			}
+			if r.Err != nil {
+				versions = append(versions, versionInfo{errMsg: errorMsg})
+				details = append(details, fmt.Sprintf("%s: %s", env, errorMsg))
+				fetchErrors = append(fetchErrors, fmt.Sprintf("%s: %s", env, r.Err.Error()))
+			} else {
+				if !r.Found {
+					versions = append(versions, versionInfo{notFound: true})
+					details = append(details, fmt.Sprintf("%s: %s", env, systemNotFound))
+				} else {
+					versions = append(versions, versionInfo{version: r.Version, registeredAt: r.RegisteredAt})
+					details = append(details, fmt.Sprintf("%s: %s", env, r.Version))
+				}
+			}
+		}
+
+		testCase := junitTestCase{
+			Name:      sys,
+			ClassName: suiteName,
+			SystemOut: strings.Join(details, "\n"),
+		}
+
+		switch {
+		case len(fetchErrors) > 0:
+			testCase.Error = &junitProblem{
+				Message: "couldn't fetch versions for all envs",
+				Type:    junitFetchErrorType,
+				Details: strings.Join(fetchErrors, "\n"),
+			}
+			suite.Errors++
+		case len(violations[sys]) > 0:
+			testCase.Failure = &junitProblem{
+				Message: "policies are violated",
+				Type:    junitViolationType,
+				Details: strings.Join(violations[sys], "\n"),
+			}
+			suite.Failures++
+		case report.Drift[sys].HasAnomaly():
+			testCase.Failure = &junitProblem{
+				Message: "a downstream env is ahead of an upstream env",
+				Type:    junitAnomalyType,
+				Details: strings.Join(append(details, report.Drift[sys].Summary()), "\n"),
+			}
+			suite.Failures++
+		case !allEqual(versions):
+			if summary := report.Drift[sys].Summary(); summary != "" {
+				details = append(details, summary)
+			}
+			testCase.Failure = &junitProblem{
+				Message: "versions are not in sync across envs",
+				Type:    junitOutOfSyncType,
+				Details: strings.Join(details, "\n"),
+			}
+			suite.Failures++
+		}
+
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+	suite.Tests = len(suite.TestCases)
+
+	return suite
+}
@@ -0,0 +1,97 @@
+package ui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dhth/ecsv/internal/types"
+)
+
+func TestGetJUnitOutput(t *testing.T) {
+	now := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	config := Config{
+		EnvSequence: []string{"qa", "staging"},
+		SystemKeys:  []string{"service-a", "service-b", "service-c"},
+		OutputFmt:   types.JUnitFmt,
+	}
+	results := map[string]map[string]types.VersionResult{
+		"service-a": {
+			"qa":      {SystemKey: "service-a", Env: "qa", Version: "v0.1.0", Found: true, RegisteredAt: &now},
+			"staging": {SystemKey: "service-a", Env: "staging", Version: "v0.1.0", Found: true, RegisteredAt: &now},
+		},
+		"service-b": {
+			"qa":      {SystemKey: "service-b", Env: "qa", Version: "v0.2.0", Found: true, RegisteredAt: &now},
+			"staging": {SystemKey: "service-b", Env: "staging", Version: "v0.1.0", Found: true, RegisteredAt: &now},
+		},
+		"service-c": {
+			"qa":      {SystemKey: "service-c", Env: "qa", Version: "v0.1.0", Found: true, RegisteredAt: &now},
+			"staging": {SystemKey: "service-c", Env: "staging", Err: errors.New("token expired")},
+		},
+	}
+
+	got, err := getJUnitOutput(config, Report{Versions: results}, now)
+	if err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+
+	expectedSnippets := []string{
+		`<testsuites name="ecsv" tests="3" failures="1" errors="1">`,
+		`<testsuite name="ecsv" tests="3" failures="1" errors="1" timestamp="2025-03-01T10:00:00">`,
+		`<testcase name="service-a" classname="ecsv">`,
+		`<failure message="versions are not in sync across envs" type="OutOfSync">qa: v0.2.0&#xA;staging: v0.1.0</failure>`,
+		`<error message="couldn&#39;t fetch versions for all envs" type="FetchError">staging: token expired</error>`,
+	}
+
+	for _, snippet := range expectedSnippets {
+		if !strings.Contains(got, snippet) {
+			t.Errorf("output doesn't contain %q; output:\n%s", snippet, got)
+		}
+	}
+
+	if strings.Count(got, "<failure") != 1 {
+		t.Errorf("expected exactly one failure; output:\n%s", got)
+	}
+}
+
+func TestGetJUnitOutputCreatesATestSuitePerGroup(t *testing.T) {
+	now := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	config := Config{
+		EnvSequence: []string{"qa", "staging"},
+		SystemKeys:  []string{"service-a", "service-b"},
+		Groups: []SystemGroup{
+			{Name: "payments", SystemKeys: []string{"service-a"}},
+			{Name: "platform", SystemKeys: []string{"service-b"}},
+		},
+		OutputFmt: types.JUnitFmt,
+	}
+	results := map[string]map[string]types.VersionResult{
+		"service-a": {
+			"qa":      {SystemKey: "service-a", Env: "qa", Version: "v0.1.0", Found: true, RegisteredAt: &now},
+			"staging": {SystemKey: "service-a", Env: "staging", Version: "v0.1.0", Found: true, RegisteredAt: &now},
+		},
+		"service-b": {
+			"qa":      {SystemKey: "service-b", Env: "qa", Version: "v0.2.0", Found: true, RegisteredAt: &now},
+			"staging": {SystemKey: "service-b", Env: "staging", Version: "v0.1.0", Found: true, RegisteredAt: &now},
+		},
+	}
+
+	got, err := getJUnitOutput(config, Report{Versions: results}, now)
+	if err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+
+	expectedSnippets := []string{
+		`<testsuites name="ecsv" tests="2" failures="1" errors="0">`,
+		`<testsuite name="payments" tests="1" failures="0" errors="0" timestamp="2025-03-01T10:00:00">`,
+		`<testsuite name="platform" tests="1" failures="1" errors="0" timestamp="2025-03-01T10:00:00">`,
+		`<testcase name="service-b" classname="platform">`,
+	}
+
+	for _, snippet := range expectedSnippets {
+		if !strings.Contains(got, snippet) {
+			t.Errorf("output doesn't contain %q; output:\n%s", snippet, got)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/dhth/ecsv/internal/types"
+	"golang.org/x/term"
+)
+
+const (
+	liveRefreshInterval = 100 * time.Millisecond
+	cursorUp            = "\x1b[%dA"
+	clearToEnd          = "\x1b[J"
+	hideCursor          = "\x1b[?25l"
+	showCursor          = "\x1b[?25h"
+)
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+var (
+	liveSystemStyle = systemStyle.MaxWidth(30)
+	liveEnvStyle    = envStyle.Width(18).MaxWidth(20)
+	liveResultStyle = resultStyle.Width(22).MaxWidth(22)
+)
+
+// LiveView renders versions in the terminal as they're fetched, with a
+// spinner for the ones that are still pending. It redraws itself in place, so
+// it's only meant to be used when writing to a terminal. Frames are fit to the
+// terminal's size, since the cursor can't be moved back above its top.
+type LiveView struct {
+	config  Config
+	out     io.Writer
+	size    func() (width, height int, ok bool)
+	mu      sync.Mutex
+	results map[string]map[string]types.VersionResult
+	pending map[string]map[string]bool
+	total   int
+	done    int
+	errors  int
+	frame   int
+	// widths of the lines last drawn, used to work out how many rows they
+	// take up (which changes if the terminal is resized)
+	lineWidths []int
+	started    time.Time
+	stop       chan struct{}
+	stopped    chan struct{}
+}
+
+// NewLiveView creates a LiveView that expects a result for every system/env
+// in pending.
+func NewLiveView(config Config, out io.Writer, pending []types.VersionsConfig) *LiveView {
+	v := &LiveView{
+		config:  config,
+		out:     out,
+		size:    terminalSize(out),
+		results: make(map[string]map[string]types.VersionResult),
+		pending: make(map[string]map[string]bool),
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+
+	for _, p := range pending {
+		if v.pending[p.Key] == nil {
+			v.pending[p.Key] = make(map[string]bool)
+		}
+		v.pending[p.Key][p.Env] = true
+		v.total++
+	}
+
+	return v
+}
+
+// Start begins redrawing the view periodically, until Finish is called.
+func (v *LiveView) Start() {
+	v.started = time.Now()
+	fmt.Fprint(v.out, hideCursor)
+
+	go func() {
+		defer close(v.stopped)
+		ticker := time.NewTicker(liveRefreshInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-v.stop:
+				return
+			case <-ticker.C:
+				v.mu.Lock()
+				v.frame++
+				v.draw()
+				v.mu.Unlock()
+			}
+		}
+	}()
+}
+
+// Update records a fetched result, and redraws the view.
+func (v *LiveView) Update(r types.VersionResult) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.results[r.SystemKey] == nil {
+		v.results[r.SystemKey] = make(map[string]types.VersionResult)
+	}
+	v.results[r.SystemKey][r.Env] = r
+	if v.pending[r.SystemKey][r.Env] {
+		v.pending[r.SystemKey][r.Env] = false
+		v.done++
+	}
+	if r.Err != nil {
+		v.errors++
+	}
+
+	v.draw()
+}
+
+// Finish stops redrawing, clears the view, and prints a one line summary in
+// its place.
+func (v *LiveView) Finish() {
+	close(v.stop)
+	<-v.stopped
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.clear()
+	summary := fmt.Sprintf("fetched %d/%d versions in %s", v.done, v.total, time.Since(v.started).Round(10*time.Millisecond))
+	if v.errors > 0 {
+		summary = fmt.Sprintf("%s (%d with errors)", summary, v.errors)
+	}
+	fmt.Fprintf(v.out, "%s\n%s", groupSummaryStyle.Render(summary), showCursor)
+}
+
+func (v *LiveView) clear() {
+	width, _, ok := v.size()
+	var rows int
+	for _, w := range v.lineWidths {
+		rows++
+		if ok && w > width {
+			// lines wider than the terminal wrap onto more rows
+			rows += (w - 1) / width
+		}
+	}
+
+	if rows > 0 {
+		fmt.Fprintf(v.out, cursorUp, rows)
+	}
+	fmt.Fprint(v.out, "\r"+clearToEnd)
+	v.lineWidths = nil
+}
+
+func (v *LiveView) draw() {
+	lines := strings.Split(strings.TrimSuffix(v.render(), "\n"), "\n")
+	if width, height, ok := v.size(); ok {
+		lines = fitLines(lines, width, height)
+	}
+
+	v.clear()
+	for _, line := range lines {
+		fmt.Fprintln(v.out, line)
+		v.lineWidths = append(v.lineWidths, ansi.StringWidth(line))
+	}
+}
+
+// fitLines truncates lines of a frame to width, and drops system rows that
+// don't fit in height, keeping the header and the progress line (which is the
+// only one kept when the terminal is really short).
+func fitLines(lines []string, width, height int) []string {
+	for i, line := range lines {
+		lines[i] = ansi.Truncate(line, width, "")
+	}
+
+	// a row is left for the cursor
+	maxLines := height - 1
+	if len(lines) <= maxLines {
+		return lines
+	}
+
+	progress := lines[len(lines)-1]
+	if maxLines < 4 {
+		return []string{progress}
+	}
+
+	// besides the header, the progress, and the line for hidden systems
+	shown := maxLines - 3
+	hidden := len(lines) - 2 - shown
+	fitted := slices.Clone(lines[:1+shown])
+	fitted = append(fitted,
+		ansi.Truncate(groupSummaryStyle.Render(fmt.Sprintf("... %d more systems", hidden)), width, ""),
+		progress,
+	)
+
+	return fitted
+}
+
+// terminalSize returns a function that reports the size of the terminal out
+// refers to, if it refers to one.
+func terminalSize(out io.Writer) func() (int, int, bool) {
+	f, ok := out.(*os.File)
+	if !ok {
+		return func() (int, int, bool) {
+			return 0, 0, false
+		}
+	}
+
+	return func() (int, int, bool) {
+		width, height, err := term.GetSize(int(f.Fd()))
+		if err != nil || width <= 0 || height <= 0 {
+			return 0, 0, false
+		}
+
+		return width, height, true
+	}
+}
+
+func (v *LiveView) render() string {
+	var s strings.Builder
+	spinner := spinnerFrames[v.frame%len(spinnerFrames)]
+
+	s.WriteString(liveSystemStyle.Render("system"))
+	for _, env := range v.config.EnvSequence {
+		fmt.Fprintf(&s, "%s    ", liveEnvStyle.Render(env))
+	}
+	s.WriteString("\n")
+
+	for _, sys := range v.config.SystemKeys {
+		s.WriteString(liveSystemStyle.Render(sys))
+		for _, env := range v.config.EnvSequence {
+			var cell string
+			r, fetched := v.results[sys][env]
+			switch {
+			case v.pending[sys][env]:
+				cell = durationStyle.Render(spinner)
+			case !fetched:
+				cell = ""
+			case r.Err != nil:
+				cell = errorStyle.Render(errorMsg)
+			case !r.Found:
+				cell = errorStyle.Render(systemNotFound)
+			default:
+				cell = versionStyle.Render(r.Version)
+			}
+			s.WriteString(liveResultStyle.Render(cell))
+		}
+		s.WriteString("\n")
+	}
+
+	elapsed := time.Since(v.started).Round(100 * time.Millisecond)
+	s.WriteString(groupSummaryStyle.Render(fmt.Sprintf("%s %d/%d fetched (%s)", spinner, v.done, v.total, elapsed)))
+	s.WriteString("\n")
+
+	return s.String()
+}
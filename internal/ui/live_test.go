@@ -0,0 +1,144 @@
+package ui
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/dhth/ecsv/internal/types"
+)
+
+func TestLiveViewShowsPendingAndFetchedResults(t *testing.T) {
+	config := Config{
+		EnvSequence: []string{"qa", "prod"},
+		SystemKeys:  []string{"service-a"},
+	}
+	pending := []types.VersionsConfig{
+		{Key: "service-a", Env: "qa"},
+		{Key: "service-a", Env: "prod"},
+	}
+
+	var out bytes.Buffer
+	view := NewLiveView(config, &out, pending)
+	view.Update(types.VersionResult{SystemKey: "service-a", Env: "qa", Version: "1.2.3", Found: true})
+
+	frame := view.render()
+	if !strings.Contains(frame, "1.2.3") {
+		t.Errorf("frame doesn't contain the fetched version:\n%s", frame)
+	}
+	if !strings.Contains(frame, spinnerFrames[0]) {
+		t.Errorf("frame doesn't contain a spinner for the pending result:\n%s", frame)
+	}
+	if !strings.Contains(frame, "1/2 fetched") {
+		t.Errorf("frame doesn't contain the progress:\n%s", frame)
+	}
+
+	view.Start()
+	view.Update(types.VersionResult{SystemKey: "service-a", Env: "prod", Err: errServiceNotFound})
+	view.Finish()
+
+	if !strings.Contains(out.String(), "fetched 2/2 versions in") {
+		t.Errorf("output doesn't contain the summary:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "(1 with errors)") {
+		t.Errorf("output doesn't contain the error count:\n%s", out.String())
+	}
+}
+
+func TestLiveViewFitsFramesToTerminal(t *testing.T) {
+	config := Config{
+		EnvSequence: []string{"qa", "staging", "prod"},
+		SystemKeys:  []string{"service-a", "service-b", "service-c", "service-d", "service-e"},
+	}
+	var pending []types.VersionsConfig
+	for _, key := range config.SystemKeys {
+		for _, env := range config.EnvSequence {
+			pending = append(pending, types.VersionsConfig{Key: key, Env: env})
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		width         int
+		height        int
+		expectedLines int
+		expected      []string
+	}{
+		{
+			name:          "frame fits",
+			width:         200,
+			height:        40,
+			expectedLines: 7,
+			expected:      []string{"service-e", "0/15 fetched"},
+		},
+		{
+			name:          "frame taller than terminal",
+			width:         200,
+			height:        6,
+			expectedLines: 5,
+			expected:      []string{"service-b", "... 3 more systems", "0/15 fetched"},
+		},
+		{
+			name:          "really short terminal",
+			width:         200,
+			height:        3,
+			expectedLines: 1,
+			expected:      []string{"0/15 fetched"},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			view := NewLiveView(config, &out, pending)
+			view.size = func() (int, int, bool) {
+				return tt.width, tt.height, true
+			}
+
+			view.draw()
+
+			if len(view.lineWidths) != tt.expectedLines {
+				t.Errorf("got %d lines, expected %d:\n%s", len(view.lineWidths), tt.expectedLines, out.String())
+			}
+			for _, snippet := range tt.expected {
+				if !strings.Contains(out.String(), snippet) {
+					t.Errorf("frame doesn't contain %q:\n%s", snippet, out.String())
+				}
+			}
+		})
+	}
+}
+
+func TestLiveViewClearsWrappedLines(t *testing.T) {
+	config := Config{
+		EnvSequence: []string{"qa", "staging", "prod"},
+		SystemKeys:  []string{"service-a"},
+	}
+	pending := []types.VersionsConfig{{Key: "service-a", Env: "qa"}}
+
+	var out bytes.Buffer
+	view := NewLiveView(config, &out, pending)
+	width := 200
+	view.size = func() (int, int, bool) {
+		return width, 40, true
+	}
+	view.draw()
+
+	var expected int
+	for _, w := range view.lineWidths {
+		expected += max(1, (w+39)/40)
+	}
+	if expected <= len(view.lineWidths) {
+		t.Fatalf("expected lines to be wider than 40 columns, got widths: %v", view.lineWidths)
+	}
+
+	// the terminal gets narrower, so the lines drawn earlier now wrap
+	width = 40
+	out.Reset()
+	view.clear()
+
+	if got := out.String(); !strings.HasPrefix(got, fmt.Sprintf(cursorUp, expected)) {
+		t.Errorf("expected cursor to move up %d rows, got: %q", expected, got)
+	}
+}
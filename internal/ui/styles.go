@@ -35,6 +35,15 @@ var (
 			Bold(true).
 			Foreground(lipgloss.Color("#b8bb26"))
 
+	groupStyle = nonFgStyle.
+			Align(lipgloss.Left).
+			Bold(true).
+			Foreground(lipgloss.Color("#d3869b")).
+			Width(30)
+
+	groupSummaryStyle = nonFgStyle.
+				Foreground(lipgloss.Color("#928374"))
+
 	resultStyle = lipgloss.NewStyle().
 			Width(34)
 
@@ -59,4 +68,40 @@ var (
 
 	errorDetailStyle = nonFgStyle.
 				Foreground(lipgloss.Color("#665c54"))
+
+	errorHintStyle = nonFgStyle.
+			Foreground(lipgloss.Color("#fabd2f"))
+
+	driftHeadingStyle = nonFgStyle.
+				Bold(true).
+				Foreground(lipgloss.Color("#fabd2f"))
+
+	driftDetailStyle = nonFgStyle.
+				Foreground(lipgloss.Color("#bdae93"))
+
+	violationStyle = versionStyle.
+			Foreground(lipgloss.Color("#d3869b")).
+			Underline(true)
+
+	violationHeadingStyle = nonFgStyle.
+				Bold(true).
+				Foreground(lipgloss.Color("#d3869b"))
+
+	violationDetailStyle = nonFgStyle.
+				Foreground(lipgloss.Color("#bdae93"))
+
+	timingsHeadingStyle = nonFgStyle.
+				Bold(true).
+				Foreground(lipgloss.Color("#83a598"))
+
+	taskDefinitionHeadingStyle = nonFgStyle.
+					Bold(true).
+					Foreground(lipgloss.Color("#8ec07c"))
+
+	taskDefinitionDetailStyle = nonFgStyle.
+					Foreground(lipgloss.Color("#bdae93"))
+
+	anomalyStyle = nonFgStyle.
+			Bold(true).
+			Foreground(lipgloss.Color("#fb4934"))
 )
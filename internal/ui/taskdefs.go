@@ -0,0 +1,93 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TaskDefinitionRow holds the details of the ECS task definition behind the
+// version of a system in an env.
+type TaskDefinitionRow struct {
+	SystemKey      string
+	Env            string
+	FamilyRevision string
+	CPU            string
+	Memory         string
+	Image          string
+	ARN            string
+}
+
+var taskDefinitionHeaders = []string{"system", "env", "task definition", "cpu/memory", "image", "arn"}
+
+func (r TaskDefinitionRow) values() []string {
+	return []string{r.SystemKey, r.Env, r.FamilyRevision, r.CPU + "/" + r.Memory, r.Image, r.ARN}
+}
+
+// taskDefinitionRows returns the task definitions behind all versions found in
+// ECS, in the order systems and envs are shown in.
+func taskDefinitionRows(config Config, report Report) []TaskDefinitionRow {
+	var rows []TaskDefinitionRow
+	for _, group := range config.groups() {
+		for _, sys := range group.SystemKeys {
+			for _, env := range config.EnvSequence {
+				td := report.Versions[sys][env].TaskDefinition
+				if td == nil {
+					continue
+				}
+
+				rows = append(rows, TaskDefinitionRow{
+					SystemKey:      sys,
+					Env:            env,
+					FamilyRevision: td.FamilyRevision(),
+					CPU:            valueOrDash(td.CPU),
+					Memory:         valueOrDash(td.Memory),
+					Image:          td.Image,
+					ARN:            td.ARN,
+				})
+			}
+		}
+	}
+
+	return rows
+}
+
+func getTaskDefinitionsTable(config Config, rows []TaskDefinitionRow) (string, error) {
+	tableRows := make([][]string, len(rows))
+	for i, r := range rows {
+		tableRows[i] = r.values()
+	}
+
+	return renderTable(config.TableConfig.Style, taskDefinitionHeaders, tableRows)
+}
+
+func getTaskDefinitionsTerminalOutput(rows []TaskDefinitionRow) string {
+	widths := make([]int, len(taskDefinitionHeaders))
+	for i, h := range taskDefinitionHeaders {
+		widths[i] = len(h)
+	}
+	for _, r := range rows {
+		for i, v := range r.values() {
+			widths[i] = max(widths[i], len(v))
+		}
+	}
+
+	var s strings.Builder
+	s.WriteString(taskDefinitionHeadingStyle.Render("Task definitions"))
+	s.WriteString("\n\n")
+
+	line := func(values []string) {
+		cells := make([]string, len(values))
+		for i, v := range values {
+			cells[i] = fmt.Sprintf("%-*s", widths[i], v)
+		}
+		s.WriteString(taskDefinitionDetailStyle.Render(strings.TrimRight(strings.Join(cells, "  "), " ")))
+		s.WriteString("\n")
+	}
+
+	line(taskDefinitionHeaders)
+	for _, r := range rows {
+		line(r.values())
+	}
+
+	return s.String()
+}
@@ -0,0 +1,78 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dhth/ecsv/internal/types"
+)
+
+func TestTaskDefinitionsInTabularOutput(t *testing.T) {
+	config := Config{
+		EnvSequence:         []string{"qa", "staging"},
+		SystemKeys:          []string{"service-a", "service-b"},
+		OutputFmt:           types.TabularFmt,
+		ShowTaskDefinitions: true,
+	}
+	results := map[string]map[string]types.VersionResult{
+		"service-a": {
+			"qa": {
+				SystemKey: "service-a", Env: "qa", Version: "1.4.2", Found: true,
+				TaskDefinition: &types.TaskDefinition{
+					ARN:      "arn:aws:ecs:eu-central-1:123456789012:task-definition/service-a-qa:42",
+					Family:   "service-a-qa",
+					Revision: 42,
+					Image:    "123456789012.dkr.ecr.eu-central-1.amazonaws.com/service-a:1.4.2",
+					CPU:      "256",
+					Memory:   "512",
+				},
+			},
+			"staging": {
+				SystemKey: "service-a", Env: "staging", Version: "1.4.2", Found: true,
+				TaskDefinition: &types.TaskDefinition{
+					ARN:      "arn:aws:ecs:eu-central-1:123456789012:task-definition/service-a-staging:7",
+					Family:   "service-a-staging",
+					Revision: 7,
+					Image:    "123456789012.dkr.ecr.eu-central-1.amazonaws.com/service-a:1.4.2",
+				},
+			},
+		},
+		"service-b": {
+			// versions from sources other than ECS don't have task definitions
+			"qa": {SystemKey: "service-b", Env: "qa", Version: "2.0.0", Found: true},
+		},
+	}
+
+	got, err := GetOutput(config, Report{Versions: results})
+	if err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+
+	expectedSnippets := []string{
+		"task definition",
+		"service-a-qa:42",
+		"256/512",
+		"service-a-staging:7",
+		"-/-",
+		"arn:aws:ecs:eu-central-1:123456789012:task-definition/service-a-qa:42",
+	}
+	for _, snippet := range expectedSnippets {
+		if !strings.Contains(got, snippet) {
+			t.Errorf("output doesn't contain %q; got:\n%s", snippet, got)
+		}
+	}
+
+	if rows := taskDefinitionRows(config, Report{Versions: results}); len(rows) != 2 {
+		t.Errorf("got %d task definition rows, expected 2", len(rows))
+	}
+
+	config.ShowTaskDefinitions = false
+	got, err = GetOutput(config, Report{Versions: results})
+	if err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+
+	if strings.Contains(got, "service-a-qa:42") {
+		t.Errorf("output contains task definitions even though they're not to be shown; got:\n%s", got)
+	}
+}
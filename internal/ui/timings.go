@@ -0,0 +1,62 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dhth/ecsv/internal/types"
+)
+
+// GetTimingsOutput renders a breakdown of fetch timings per source.
+func GetTimingsOutput(timings []types.FetchTiming) string {
+	if len(timings) == 0 {
+		return ""
+	}
+
+	width := len("source")
+	for _, t := range timings {
+		width = max(width, len(t.Source))
+	}
+
+	var s strings.Builder
+	s.WriteString(timingsHeadingStyle.Render("Timings"))
+	s.WriteString("\n\n")
+
+	row := func(values ...string) {
+		fmt.Fprintf(&s, "  %-*s  %7s  %5s  %9s  %9s  %9s  %9s\n", width, values[0], values[1], values[2], values[3], values[4], values[5], values[6])
+	}
+
+	row("source", "fetches", "limit", "elapsed", "average", "slowest", "waited")
+	for _, t := range timings {
+		limit := "-"
+		if t.Limit > 0 {
+			limit = strconv.Itoa(t.Limit)
+		}
+
+		row(
+			t.Source,
+			strconv.Itoa(t.Fetches),
+			limit,
+			roundDuration(t.Elapsed()),
+			roundDuration(averageDuration(t)),
+			roundDuration(t.Slowest),
+			roundDuration(t.Waited),
+		)
+	}
+
+	return s.String()
+}
+
+func averageDuration(t types.FetchTiming) time.Duration {
+	if t.Fetches == 0 {
+		return 0
+	}
+
+	return t.Total / time.Duration(t.Fetches)
+}
+
+func roundDuration(d time.Duration) string {
+	return d.Round(time.Millisecond).String()
+}
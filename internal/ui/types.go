@@ -4,31 +4,89 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/dhth/ecsv/internal/drift"
+	"github.com/dhth/ecsv/internal/policy"
 	"github.com/dhth/ecsv/internal/types"
 )
 
 type VersionRow struct {
-	Data   []string
-	InSync bool
+	Data    []string
+	InSync  bool
+	Drift   string
+	Anomaly bool
+}
+
+// HTMLGroup holds the rows for a group of systems, along with how many of them
+// are in sync.
+type HTMLGroup struct {
+	Name   string
+	InSync int
+	Total  int
+	Rows   []VersionRow
 }
 
 type HTMLData struct {
-	Title     string
-	TitleURL  string
-	Columns   []string
-	Rows      []VersionRow
-	Changes   []types.ChangesResult
-	Errors    []error
-	Timestamp string
+	Title      string
+	TitleURL   string
+	Columns    []string
+	Rows       []VersionRow
+	Groups     []HTMLGroup
+	Changes    []types.ChangesResult
+	Violations []policy.Violation
+	Errors     []ErrorGroup
+	// TaskDefinitions is only set if task definitions are to be shown
+	TaskDefinitions []TaskDefinitionRow
+	Timestamp       string
 }
 
 type Config struct {
 	EnvSequence      []string
 	SystemKeys       []string
+	Groups           []SystemGroup
 	OutputFmt        types.OutputFmt
 	HTMLConfig       HTMLOutputConfig
 	TableConfig      TableOutputConfig
 	ShowRegisteredAt bool
+	// ShowTaskDefinitions indicates whether the ECS task definitions behind
+	// versions should be shown
+	ShowTaskDefinitions bool
+	// Live indicates whether results should be shown as they're fetched
+	Live bool
+}
+
+// SystemGroup is a set of systems that are to be shown together, eg. the
+// systems owned by a team.
+type SystemGroup struct {
+	Name       string
+	SystemKeys []string
+}
+
+func (c Config) groups() []SystemGroup {
+	if len(c.Groups) == 0 {
+		return []SystemGroup{{SystemKeys: c.SystemKeys}}
+	}
+
+	return c.Groups
+}
+
+// Report holds everything gathered during a check that needs to be rendered.
+type Report struct {
+	Versions   map[string]map[string]types.VersionResult
+	Drift      map[string]drift.Analysis
+	Changes    []types.ChangesResult
+	Violations []policy.Violation
+}
+
+func (r Report) violatedCells() map[string]map[string]bool {
+	cells := make(map[string]map[string]bool)
+	for _, v := range r.Violations {
+		if cells[v.SystemKey] == nil {
+			cells[v.SystemKey] = make(map[string]bool)
+		}
+		cells[v.SystemKey][v.Env] = true
+	}
+
+	return cells
 }
 
 type HTMLOutputConfig struct {
@@ -52,6 +110,7 @@ func (c Config) String() string {
 - html title            %s
 - html title url        %s
 - show registererd url  %v
+- show task defs        %v
 `,
 			c.EnvSequence,
 			c.SystemKeys,
@@ -59,6 +118,7 @@ func (c Config) String() string {
 			c.HTMLConfig.Title,
 			c.HTMLConfig.TitleURL,
 			c.ShowRegisteredAt,
+			c.ShowTaskDefinitions,
 		))
 	case types.TabularFmt:
 		return strings.TrimSpace(fmt.Sprintf(`
@@ -66,11 +126,23 @@ func (c Config) String() string {
 - system keys           %v
 - output format         %s
 - style                 %s
+- show task defs        %v
 `,
 			c.EnvSequence,
 			c.SystemKeys,
 			c.OutputFmt.String(),
 			c.TableConfig.Style.String(),
+			c.ShowTaskDefinitions,
+		))
+	case types.JUnitFmt:
+		return strings.TrimSpace(fmt.Sprintf(`
+- env sequence          %v
+- system keys           %v
+- output format         %s
+`,
+			c.EnvSequence,
+			c.SystemKeys,
+			c.OutputFmt.String(),
 		))
 	default:
 		return strings.TrimSpace(fmt.Sprintf(`
@@ -78,11 +150,15 @@ func (c Config) String() string {
 - system keys           %v
 - output format         %s
 - show registererd url  %v
+- show task defs        %v
+- live                  %v
 `,
 			c.EnvSequence,
 			c.SystemKeys,
 			c.OutputFmt.String(),
 			c.ShowRegisteredAt,
+			c.ShowTaskDefinitions,
+			c.Live,
 		))
 	}
 }
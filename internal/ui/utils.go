@@ -1,9 +1,10 @@
 package ui
 
 import (
-	"fmt"
 	"strings"
 	"time"
+
+	"github.com/dhth/ecsv/internal/utils"
 )
 
 func RightPadTrim(s string, length int) string {
@@ -27,21 +28,7 @@ func Trim(s string, length int) string {
 }
 
 func HumanizeDuration(durationInSecs int) string {
-	duration := time.Duration(durationInSecs) * time.Second
-
-	if duration.Hours() > 48 {
-		return fmt.Sprintf("%dd", int(duration.Hours()/24))
-	}
-
-	if duration.Seconds() < 60 {
-		return fmt.Sprintf("%ds", int(duration.Seconds()))
-	}
-
-	if duration.Minutes() < 60 {
-		return fmt.Sprintf("%dm", int(duration.Minutes()))
-	}
-
-	return fmt.Sprintf("%dh", int(duration.Hours()))
+	return utils.HumanizeDuration(time.Duration(durationInSecs) * time.Second)
 }
 
 func allEqual(versions []versionInfo) bool {
@@ -17,8 +17,9 @@ import (
 )
 
 const (
-	errorMsg       = "error"
-	systemNotFound = "not found"
+	errorMsg        = "error"
+	systemNotFound  = "not found"
+	violationMarker = "[!]"
 )
 
 var (
@@ -32,24 +33,83 @@ var (
 //go:embed assets/template.html
 var builtInHTMLTemplate string
 
-func GetOutput(config Config,
-	versionResults map[string]map[string]types.VersionResult,
-	changesResults []types.ChangesResult,
-) (string, error) {
+func GetOutput(config Config, report Report) (string, error) {
 	switch config.OutputFmt {
 	case types.TabularFmt:
-		return getTabularOutput(config, versionResults)
+		return getTabularOutput(config, report)
 	case types.HTMLFmt:
-		return getHTMLOutput(config, versionResults, changesResults)
+		return getHTMLOutput(config, report)
+	case types.JUnitFmt:
+		return getJUnitOutput(config, report, time.Now())
 	default:
-		return getTerminalOutput(config, versionResults), nil
+		return getTerminalOutput(config, report), nil
 	}
 }
 
-func getTabularOutput(config Config, results map[string]map[string]types.VersionResult) (string, error) {
-	rows := make([][]string, 0, len(config.SystemKeys))
+func getTabularOutput(config Config, report Report) (string, error) {
+	results := report.Versions
+	violated := report.violatedCells()
 
-	for _, sys := range config.SystemKeys {
+	headers := make([]string, 0, len(config.EnvSequence)+3)
+	headers = append(headers, "system")
+	headers = append(headers, "in-sync")
+	headers = append(headers, config.EnvSequence...)
+	headers = append(headers, "drift")
+
+	var output strings.Builder
+	for i, group := range config.groups() {
+		groupOutput, inSyncCount, err := getTabularGroupOutput(config, report, group, headers, results, violated)
+		if err != nil {
+			return "", err
+		}
+
+		if group.Name != "" {
+			if i > 0 {
+				output.WriteString("\n")
+			}
+			fmt.Fprintf(&output, "%s (%d/%d in sync)\n", group.Name, inSyncCount, len(group.SystemKeys))
+		}
+		output.WriteString(groupOutput)
+	}
+
+	if config.ShowTaskDefinitions {
+		if rows := taskDefinitionRows(config, report); len(rows) > 0 {
+			taskDefinitionsOutput, err := getTaskDefinitionsTable(config, rows)
+			if err != nil {
+				return "", err
+			}
+			output.WriteString("\n" + taskDefinitionsOutput)
+		}
+	}
+
+	if len(report.Violations) == 0 {
+		return output.String(), nil
+	}
+
+	violationRows := make([][]string, len(report.Violations))
+	for i, v := range report.Violations {
+		violationRows[i] = []string{v.SystemKey, v.Env, v.Policy, v.Message}
+	}
+
+	violationsOutput, err := renderTable(config.TableConfig.Style, []string{"system", "env", "policy", "violation"}, violationRows)
+	if err != nil {
+		return "", err
+	}
+
+	return output.String() + "\n" + violationsOutput, nil
+}
+
+func getTabularGroupOutput(config Config,
+	report Report,
+	group SystemGroup,
+	headers []string,
+	results map[string]map[string]types.VersionResult,
+	violated map[string]map[string]bool,
+) (string, int, error) {
+	rows := make([][]string, 0, len(group.SystemKeys))
+	inSyncCount := 0
+
+	for _, sys := range group.SystemKeys {
 		var row []string
 
 		var versions []versionInfo
@@ -63,15 +123,16 @@ func getTabularOutput(config Config, results map[string]map[string]types.Version
 				versions = append(versions, versionInfo{errMsg: errorMsg})
 			} else {
 				if !r.Found {
-					versions = append(versions, versionInfo{notFound: true})
+					versions = append(versions, versionInfo{notFound: true, violated: violated[sys][env]})
 				} else {
-					versions = append(versions, versionInfo{version: r.Version, registeredAt: r.RegisteredAt})
+					versions = append(versions, versionInfo{version: r.Version, registeredAt: r.RegisteredAt, violated: violated[sys][env]})
 				}
 			}
 		}
 		var inSync string
 		if allEqual(versions) {
 			inSync = "YES"
+			inSyncCount++
 		} else {
 			inSync = "NO"
 		}
@@ -82,29 +143,34 @@ func getTabularOutput(config Config, results map[string]map[string]types.Version
 			if v.errMsg != "" {
 				row = append(row, v.errMsg)
 			} else if v.notFound {
-				row = append(row, systemNotFound)
+				row = append(row, withViolationMarker(systemNotFound, v.violated))
 			} else if v.version == "" {
 				row = append(row, "")
 			} else {
-				if config.ShowRegisteredAt {
+				if config.ShowRegisteredAt && v.registeredAt != nil {
 					duration := int(time.Since(*v.registeredAt).Seconds())
 					durationMsg := fmt.Sprintf("(%s ago)", HumanizeDuration(duration))
-					row = append(row, fmt.Sprintf("%s %s", v.version, durationMsg))
+					row = append(row, withViolationMarker(fmt.Sprintf("%s %s", v.version, durationMsg), v.violated))
 				} else {
-					row = append(row, v.version)
+					row = append(row, withViolationMarker(v.version, v.violated))
 				}
 			}
 		}
+		row = append(row, report.Drift[sys].Summary())
 		rows = append(rows, row)
 	}
 
-	headers := make([]string, 0, len(config.EnvSequence)+2)
-	headers = append(headers, "system")
-	headers = append(headers, "in-sync")
-	headers = append(headers, config.EnvSequence...)
+	output, err := renderTable(config.TableConfig.Style, headers, rows)
+	if err != nil {
+		return "", 0, err
+	}
 
+	return output, inSyncCount, nil
+}
+
+func renderTable(tableStyle types.TableStyle, headers []string, rows [][]string) (string, error) {
 	var style tw.BorderStyle
-	switch config.TableConfig.Style {
+	switch tableStyle {
 	case types.BlankStyle:
 		style = tw.StyleNone
 	case types.DotsStyle:
@@ -152,9 +218,20 @@ type versionInfo struct {
 	errMsg       string
 	registeredAt *time.Time
 	notFound     bool
+	violated     bool
+}
+
+func withViolationMarker(value string, violated bool) string {
+	if !violated {
+		return value
+	}
+
+	return value + " " + violationMarker
 }
 
-func getTerminalOutput(config Config, results map[string]map[string]types.VersionResult) string {
+func getTerminalOutput(config Config, report Report) string {
+	results := report.Versions
+	violated := report.violatedCells()
 	var s strings.Builder
 
 	s.WriteString("\n")
@@ -178,88 +255,153 @@ func getTerminalOutput(config Config, results map[string]map[string]types.Versio
 		fmt.Fprintf(&s, "%s    ", envSt.Render(env))
 	}
 	s.WriteString("\n\n")
-	errorIndex := 0
-	var errors []error
-
-	for _, sys := range config.SystemKeys {
-		s.WriteString(systemStyle.Render(sys))
-		var versions []versionInfo
-		for _, env := range config.EnvSequence {
-			r, ok := results[sys][env]
-			if !ok {
-				versions = append(versions, versionInfo{})
-				continue
+	errGroups := newErrorGroups()
+
+	for i, group := range config.groups() {
+		var rows strings.Builder
+		inSyncCount := 0
+
+		for _, sys := range group.SystemKeys {
+			rows.WriteString(systemStyle.Render(sys))
+			var versions []versionInfo
+			for _, env := range config.EnvSequence {
+				r, ok := results[sys][env]
+				if !ok {
+					versions = append(versions, versionInfo{})
+					continue
+				}
+				if r.Err != nil {
+					errorIndex := errGroups.add(sys, env, r.Err)
+					versions = append(versions, versionInfo{errMsg: fmt.Sprintf("%s [%d]", errorMsg, errorIndex)})
+				} else {
+					if !r.Found {
+						versions = append(versions, versionInfo{notFound: true, violated: violated[sys][env]})
+					} else {
+						versions = append(versions, versionInfo{version: r.Version, registeredAt: r.RegisteredAt, violated: violated[sys][env]})
+					}
+				}
 			}
-			if r.Err != nil {
-				versions = append(versions, versionInfo{errMsg: fmt.Sprintf("%s [%d]", errorMsg, errorIndex)})
-				errors = append(errors, r.Err)
-				errorIndex++
+
+			var style lipgloss.Style
+			if allEqual(versions) {
+				style = inSyncStyle
+				inSyncCount++
 			} else {
-				if !r.Found {
-					versions = append(versions, versionInfo{notFound: true})
+				style = outOfSyncStyle
+			}
+
+			for _, v := range versions {
+				versionSt := style
+				if v.violated {
+					versionSt = violationStyle
+				}
+
+				if v.errMsg != "" {
+					rows.WriteString(resultSt.Render(errorStyle.Render(v.errMsg)))
+				} else if v.notFound {
+					rows.WriteString(resultSt.Render(errorStyle.Render(withViolationMarker(systemNotFound, v.violated))))
+				} else if v.version == "" {
+					rows.WriteString(resultSt.Render(""))
 				} else {
-					versions = append(versions, versionInfo{version: r.Version, registeredAt: r.RegisteredAt})
+					if config.ShowRegisteredAt && v.registeredAt != nil {
+						duration := int(time.Since(*v.registeredAt).Seconds())
+						durationMsg := fmt.Sprintf("(%s ago)", HumanizeDuration(duration))
+						rows.WriteString(resultSt.Render(fmt.Sprintf("%s %s", versionSt.Render(v.version), durationStyle.Render(durationMsg))))
+					} else {
+						rows.WriteString(resultSt.Render(versionSt.Render(v.version)))
+					}
 				}
 			}
+			rows.WriteString("\n")
 		}
 
-		var style lipgloss.Style
-		if allEqual(versions) {
-			style = inSyncStyle
-		} else {
-			style = outOfSyncStyle
+		if group.Name != "" {
+			if i > 0 {
+				s.WriteString("\n")
+			}
+			s.WriteString(groupStyle.Render(group.Name))
+			s.WriteString(groupSummaryStyle.Render(fmt.Sprintf("%d/%d in sync", inSyncCount, len(group.SystemKeys))))
+			s.WriteString("\n")
+		}
+		s.WriteString(rows.String())
+	}
+
+	if config.ShowTaskDefinitions {
+		if rows := taskDefinitionRows(config, report); len(rows) > 0 {
+			s.WriteString("\n")
+			s.WriteString(getTaskDefinitionsTerminalOutput(rows))
 		}
+	}
 
-		for _, v := range versions {
-			if v.errMsg != "" {
-				s.WriteString(resultSt.Render(errorStyle.Render(v.errMsg)))
-			} else if v.notFound {
-				s.WriteString(resultSt.Render(errorStyle.Render(systemNotFound)))
-			} else if v.version == "" {
-				s.WriteString(resultSt.Render(""))
+	var driftLines []string
+	for _, sys := range config.SystemKeys {
+		for _, d := range report.Drift[sys].OutOfSync() {
+			if d.IsAnomaly() {
+				driftLines = append(driftLines, systemStyle.Render(sys)+anomalyStyle.Render("anomaly: "+d.String()))
 			} else {
-				if config.ShowRegisteredAt {
-					duration := int(time.Since(*v.registeredAt).Seconds())
-					durationMsg := fmt.Sprintf("(%s ago)", HumanizeDuration(duration))
-					s.WriteString(resultSt.Render(fmt.Sprintf("%s %s", style.Render(v.version), durationStyle.Render(durationMsg))))
-				} else {
-					s.WriteString(resultSt.Render(style.Render(v.version)))
-				}
+				driftLines = append(driftLines, systemStyle.Render(sys)+driftDetailStyle.Render(d.String()))
 			}
 		}
+	}
+
+	if len(driftLines) > 0 {
+		s.WriteString("\n")
+		s.WriteString(driftHeadingStyle.Render("Drift"))
+		s.WriteString("\n")
+		for _, line := range driftLines {
+			s.WriteString(line)
+			s.WriteString("\n")
+		}
+	}
+
+	if len(report.Violations) > 0 {
 		s.WriteString("\n")
+		s.WriteString(violationHeadingStyle.Render("Policy violations"))
+		s.WriteString("\n")
+		for _, v := range report.Violations {
+			s.WriteString(systemStyle.Render(v.SystemKey))
+			s.WriteString(violationDetailStyle.Render(v.String()))
+			s.WriteString("\n")
+		}
 	}
 
-	if len(errors) > 0 {
+	if len(errGroups.groups) > 0 {
 		s.WriteString("\n")
 		s.WriteString(errorHeadingStyle.Render("Errors"))
 		s.WriteString("\n")
-		for index, err := range errors {
-			s.WriteString(errorDetailStyle.Render(fmt.Sprintf("[%d]: %s", index, err.Error())))
+		for index, group := range errGroups.groups {
+			s.WriteString(errorDetailStyle.Render(fmt.Sprintf("[%d]: %s", index, group.Message)))
+			s.WriteString("\n")
+			if group.Hint != "" {
+				s.WriteString(errorHintStyle.Render(fmt.Sprintf("hint: %s", group.Hint)))
+				s.WriteString("\n")
+			}
+			s.WriteString(errorDetailStyle.Render(fmt.Sprintf("affects: %s", strings.Join(group.Affected, ", "))))
 			s.WriteString("\n")
 		}
 	}
 	return s.String()
 }
 
-func getHTMLOutput(config Config,
-	versionResults map[string]map[string]types.VersionResult,
-	changesResults []types.ChangesResult,
-) (string, error) {
+func getHTMLOutput(config Config, report Report) (string, error) {
+	versionResults := report.Versions
+	violated := report.violatedCells()
+	// columns line up with each row's Data; drift is exposed separately via
+	// VersionRow.Drift
 	columns := make([]string, 0, len(config.EnvSequence)+1)
 	rows := make([]VersionRow, len(config.SystemKeys))
 
 	data := HTMLData{
-		Title:    config.HTMLConfig.Title,
-		TitleURL: config.HTMLConfig.TitleURL,
-		Changes:  changesResults,
+		Title:      config.HTMLConfig.Title,
+		TitleURL:   config.HTMLConfig.TitleURL,
+		Changes:    report.Changes,
+		Violations: report.Violations,
 	}
 
 	columns = append(columns, "system")
 	columns = append(columns, config.EnvSequence...)
 
-	errorIndex := 0
-	var errors []error
+	errGroups := newErrorGroups()
 	for i, sys := range config.SystemKeys {
 		var rowData []string
 		rowData = append(rowData, sys)
@@ -272,15 +414,14 @@ func getHTMLOutput(config Config,
 				continue
 			}
 			if r.Err != nil {
+				errorIndex := errGroups.add(sys, env, r.Err)
 				versions = append(versions, versionInfo{errMsg: fmt.Sprintf("%s [%d]", errorMsg, errorIndex)})
-				errorIndex++
-				errors = append(errors, r.Err)
 				inSync = false
 			} else {
 				if !r.Found {
-					versions = append(versions, versionInfo{notFound: true})
+					versions = append(versions, versionInfo{notFound: true, violated: violated[sys][env]})
 				} else {
-					versions = append(versions, versionInfo{version: r.Version, registeredAt: r.RegisteredAt})
+					versions = append(versions, versionInfo{version: r.Version, registeredAt: r.RegisteredAt, violated: violated[sys][env]})
 				}
 			}
 		}
@@ -292,29 +433,54 @@ func getHTMLOutput(config Config,
 			if v.errMsg != "" {
 				rowData = append(rowData, v.errMsg)
 			} else if v.notFound {
-				rowData = append(rowData, systemNotFound)
+				rowData = append(rowData, withViolationMarker(systemNotFound, v.violated))
 			} else if v.version == "" {
 				rowData = append(rowData, "")
 			} else {
-				if config.ShowRegisteredAt {
+				if config.ShowRegisteredAt && v.registeredAt != nil {
 					duration := int(time.Since(*v.registeredAt).Seconds())
 					durationMsg := fmt.Sprintf("(%s ago)", HumanizeDuration(duration))
-					rowData = append(rowData, fmt.Sprintf("%s %s", v.version, durationMsg))
+					rowData = append(rowData, withViolationMarker(fmt.Sprintf("%s %s", v.version, durationMsg), v.violated))
 				} else {
-					rowData = append(rowData, v.version)
+					rowData = append(rowData, withViolationMarker(v.version, v.violated))
 				}
 			}
 		}
 
 		rows[i] = VersionRow{
-			Data:   rowData,
-			InSync: inSync,
+			Data:    rowData,
+			InSync:  inSync,
+			Drift:   report.Drift[sys].Summary(),
+			Anomaly: report.Drift[sys].HasAnomaly(),
 		}
 	}
 	data.Columns = columns
 	data.Rows = rows
-	if len(errors) > 0 {
-		data.Errors = errors
+
+	rowIndex := make(map[string]int, len(config.SystemKeys))
+	for i, sys := range config.SystemKeys {
+		rowIndex[sys] = i
+	}
+
+	for _, group := range config.groups() {
+		htmlGroup := HTMLGroup{
+			Name:  group.Name,
+			Total: len(group.SystemKeys),
+			Rows:  make([]VersionRow, 0, len(group.SystemKeys)),
+		}
+		for _, sys := range group.SystemKeys {
+			row := rows[rowIndex[sys]]
+			if row.InSync {
+				htmlGroup.InSync++
+			}
+			htmlGroup.Rows = append(htmlGroup.Rows, row)
+		}
+		data.Groups = append(data.Groups, htmlGroup)
+	}
+
+	data.Errors = errGroups.groups
+	if config.ShowTaskDefinitions {
+		data.TaskDefinitions = taskDefinitionRows(config, report)
 	}
 	data.Timestamp = time.Now().Format("2006-01-02 15:04:05 MST")
 
@@ -0,0 +1,71 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dhth/ecsv/internal/types"
+)
+
+func TestOutputHandlesMissingRegisteredAt(t *testing.T) {
+	registeredAt := time.Now().Add(-2 * time.Hour)
+	results := map[string]map[string]types.VersionResult{
+		"service-a": {
+			"qa": {SystemKey: "service-a", Env: "qa", Version: "1.4.2", Found: true, RegisteredAt: &registeredAt},
+			// sources like Kubernetes don't report when a version was deployed
+			"staging": {SystemKey: "service-a", Env: "staging", Version: "1.4.1", Found: true},
+		},
+	}
+
+	for _, outputFmt := range []types.OutputFmt{types.DefaultFmt, types.TabularFmt, types.HTMLFmt} {
+		t.Run(outputFmt.String(), func(t *testing.T) {
+			config := Config{
+				EnvSequence:      []string{"qa", "staging"},
+				SystemKeys:       []string{"service-a"},
+				OutputFmt:        outputFmt,
+				ShowRegisteredAt: true,
+			}
+
+			got, err := GetOutput(config, Report{Versions: results})
+			if err != nil {
+				t.Fatalf("got unexpected error: %s", err.Error())
+			}
+
+			for _, snippet := range []string{"1.4.2", "2h ago", "1.4.1"} {
+				if !strings.Contains(got, snippet) {
+					t.Errorf("output doesn't contain %q; got:\n%s", snippet, got)
+				}
+			}
+		})
+	}
+}
+
+func TestHTMLColumnsLineUpWithRowData(t *testing.T) {
+	config := Config{
+		EnvSequence: []string{"qa", "staging"},
+		SystemKeys:  []string{"service-a", "service-b"},
+		OutputFmt:   types.HTMLFmt,
+		HTMLConfig: HTMLOutputConfig{
+			Template: `{{len .Columns}}{{range .Rows}} {{len .Data}}{{end}}`,
+		},
+	}
+	results := map[string]map[string]types.VersionResult{
+		"service-a": {
+			"qa":      {SystemKey: "service-a", Env: "qa", Version: "1.4.2", Found: true},
+			"staging": {SystemKey: "service-a", Env: "staging", Version: "1.4.1", Found: true},
+		},
+		"service-b": {
+			"qa": {SystemKey: "service-b", Env: "qa", Version: "2.0.0", Found: true},
+		},
+	}
+
+	got, err := GetOutput(config, Report{Versions: results})
+	if err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+
+	if got != "3 3 3" {
+		t.Errorf("expected columns and row data to have the same length, got: %q", got)
+	}
+}
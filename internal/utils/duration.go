@@ -0,0 +1,22 @@
+package utils
+
+import (
+	"fmt"
+	"time"
+)
+
+func HumanizeDuration(duration time.Duration) string {
+	if duration.Hours() > 48 {
+		return fmt.Sprintf("%dd", int(duration.Hours()/24))
+	}
+
+	if duration.Seconds() < 60 {
+		return fmt.Sprintf("%ds", int(duration.Seconds()))
+	}
+
+	if duration.Minutes() < 60 {
+		return fmt.Sprintf("%dm", int(duration.Minutes()))
+	}
+
+	return fmt.Sprintf("%dh", int(duration.Hours()))
+}
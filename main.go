@@ -30,6 +30,10 @@ Let @dhth know about this via https://github.com/dhth/ecsv/issues.
 `)
 		}
 
+		if followUp.ExitCode != 0 {
+			os.Exit(followUp.ExitCode)
+		}
+
 		os.Exit(1)
 	}
 }
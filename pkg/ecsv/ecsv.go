@@ -0,0 +1,271 @@
+// Package ecsv lets Go programs check the versions of systems across envs, the
+// same way the ecsv CLI does, without printing anything. Versions can come
+// from ECS services, Lambda functions, Kubernetes deployments, HTTP endpoints,
+// SSM parameters, CloudFormation outputs, or a custom VersionSource.
+//
+// A typical use looks like the following.
+//
+//	config, err := ecsv.ParseConfig(configBytes, ecsv.Filters{})
+//	if err != nil {
+//		return err
+//	}
+//
+//	report, err := ecsv.Check(ctx, config, ecsv.WithMaxConcurrentFetches(5))
+//	if err != nil {
+//		return err
+//	}
+//
+//	for _, violation := range report.Violations {
+//		fmt.Println(violation)
+//	}
+package ecsv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/dhth/ecsv/internal/check"
+	"github.com/dhth/ecsv/internal/drift"
+	"github.com/dhth/ecsv/internal/policy"
+	"github.com/dhth/ecsv/internal/types"
+	"github.com/google/go-github/v72/github"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultMaxConcurrentFetches is the global limit on concurrent fetches used
+// unless WithMaxConcurrentFetches is provided.
+const DefaultMaxConcurrentFetches = 10
+
+var (
+	ErrConfigIsInvalid     = check.ErrConfigIsInvalid
+	ErrIncludeNotSupported = errors.New("include is not supported when parsing config")
+	ErrOptionIsInvalid     = errors.New("invalid option provided")
+	ErrSystemNotFound      = errors.New("system not found in config")
+	ErrNoVersionSource     = check.ErrNoVersionSource
+)
+
+type (
+	// Filters determines which systems and envs from the config are to be
+	// checked.
+	Filters = types.Filters
+	// VersionResult is the version found for a system in an env.
+	VersionResult = types.VersionResult
+	// ChangesResult holds the commits between the versions running in two
+	// envs.
+	ChangesResult = types.ChangesResult
+	// TaskDefinition describes the ECS task definition that a version was
+	// found in.
+	TaskDefinition = types.TaskDefinition
+	// Commit is a commit that's part of a ChangesResult.
+	Commit = types.Commit
+	// DriftAnalysis holds the drift between each pair of consecutive envs.
+	DriftAnalysis = drift.Analysis
+	// Drift describes how the versions running in two consecutive envs differ.
+	Drift = drift.Drift
+	// DriftDirection is the direction of a Drift.
+	DriftDirection = drift.Direction
+	// Violation is a policy that's not being followed.
+	Violation = policy.Violation
+	// FetchTiming is a breakdown of how long fetches from a single source
+	// took.
+	FetchTiming = types.FetchTiming
+	// CredentialsError is the error for versions that couldn't be fetched
+	// because of a problem with AWS credentials.
+	CredentialsError = types.CredentialsError
+	// SystemConfig is the config for a system in an env, as passed to a
+	// VersionSource.
+	SystemConfig = types.VersionsConfig
+	// SourceKind identifies where the version of a system in an env comes
+	// from, as set via "source" in the config.
+	SourceKind = types.SourceKind
+	// VersionSource fetches the version of a system in an env from a
+	// backend.
+	VersionSource = check.VersionSource
+)
+
+// Kinds of version sources that ecsv supports.
+const (
+	ECSSource        = types.ECSSource
+	LambdaSource     = types.LambdaSource
+	KubernetesSource = types.KubernetesSource
+	HTTPSource       = types.HTTPSource
+	SSMSource        = types.SSMSource
+	CFNSource        = types.CFNSource
+)
+
+// Directions that a Drift can have.
+const (
+	InSync          = drift.InSync
+	UpstreamAhead   = drift.UpstreamAhead
+	DownstreamAhead = drift.DownstreamAhead
+	Incomparable    = drift.Incomparable
+)
+
+// Config is ecsv's parsed config.
+type Config struct {
+	envSequence []string
+	config      types.Config
+}
+
+// ParseConfig parses ecsv's config file (in YAML), keeping only the systems
+// and envs that match filters. Since config is parsed from bytes, include is
+// not supported; merge such files beforehand.
+func ParseConfig(data []byte, filters Filters) (Config, error) {
+	var zero Config
+
+	var ecsvConfig types.ECSVConfig
+	err := yaml.Unmarshal(data, &ecsvConfig)
+	if err != nil {
+		return zero, fmt.Errorf("%w: %w", ErrConfigIsInvalid, err)
+	}
+
+	if len(ecsvConfig.Include) > 0 {
+		return zero, ErrIncludeNotSupported
+	}
+
+	envSequence, config, err := check.ReadConfig(ecsvConfig, filters)
+	if errors.Is(err, ErrConfigIsInvalid) {
+		return zero, err
+	}
+	if err != nil {
+		return zero, fmt.Errorf("%w: %w", ErrConfigIsInvalid, err)
+	}
+
+	return Config{envSequence: envSequence, config: config}, nil
+}
+
+// EnvSequence returns the envs in the order versions are promoted through
+// them (excluding filtered out envs).
+func (c Config) EnvSequence() []string {
+	return slices.Clone(c.envSequence)
+}
+
+// SystemKeys returns the keys of all systems in the config, in the order they
+// appear in.
+func (c Config) SystemKeys() []string {
+	return check.SystemKeys(c.config)
+}
+
+type options struct {
+	maxConcurrentFetches int
+	sources              map[SourceKind]VersionSource
+	githubClient         *github.Client
+	onResult             func(VersionResult)
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		maxConcurrentFetches: DefaultMaxConcurrentFetches,
+		sources:              check.DefaultSources(),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// Option configures a check.
+type Option func(*options)
+
+// WithMaxConcurrentFetches sets the global limit on concurrent fetches. Limits
+// set via concurrency in the config apply on top of this.
+func WithMaxConcurrentFetches(n int) Option {
+	return func(o *options) {
+		o.maxConcurrentFetches = n
+	}
+}
+
+// WithVersionSource makes versions of systems whose source is kind get fetched
+// via source, eg. for using a mock in tests, or for supporting a backend that
+// ecsv doesn't.
+func WithVersionSource(kind SourceKind, source VersionSource) Option {
+	return func(o *options) {
+		o.sources[kind] = source
+	}
+}
+
+// WithGitHubClient provides a client for fetching the commits between versions,
+// for systems that have changes configured. Changes are not fetched without
+// it.
+func WithGitHubClient(client *github.Client) Option {
+	return func(o *options) {
+		o.githubClient = client
+	}
+}
+
+// WithResultHandler provides a function that gets called with every version
+// result as soon as it's fetched, eg. for showing progress. It's called from a
+// single goroutine.
+func WithResultHandler(fn func(VersionResult)) Option {
+	return func(o *options) {
+		o.onResult = fn
+	}
+}
+
+// Report holds everything gathered during a check.
+type Report struct {
+	EnvSequence []string
+	SystemKeys  []string
+	// Versions holds results keyed by system key, and then by env
+	Versions map[string]map[string]VersionResult
+	// Drift holds the drift analysis for each system, keyed by system key
+	Drift      map[string]DriftAnalysis
+	Changes    []ChangesResult
+	Violations []Violation
+	Timings    []FetchTiming
+}
+
+// Check fetches the versions of all systems in config, and analyses them for
+// drift and policy violations. Errors encountered while fetching individual
+// versions are reported in the corresponding VersionResult. If ctx is done
+// before the check finishes, the partial report is returned along with ctx's
+// error.
+func Check(ctx context.Context, config Config, opts ...Option) (Report, error) {
+	o := newOptions(opts)
+	if o.maxConcurrentFetches <= 0 {
+		return Report{}, fmt.Errorf("%w: max concurrent fetches needs to be greater than 0", ErrOptionIsInvalid)
+	}
+
+	result := check.Run(ctx, config.config, config.envSequence, check.Options{
+		MaxConcurrentFetches: o.maxConcurrentFetches,
+		Sources:              o.sources,
+		GitHubClient:         o.githubClient,
+		OnResult:             o.onResult,
+	})
+
+	report := Report{
+		EnvSequence: config.EnvSequence(),
+		SystemKeys:  config.SystemKeys(),
+		Versions:    result.Versions,
+		Drift:       result.Drift,
+		Changes:     result.Changes,
+		Violations:  result.Violations,
+		Timings:     result.Timings,
+	}
+
+	return report, ctx.Err()
+}
+
+// FetchVersion fetches the version of a single system in an env. Unlike with
+// Check, an error encountered while fetching is also returned directly. Only
+// WithVersionSource applies out of the provided options.
+func FetchVersion(ctx context.Context, config Config, systemKey, env string, opts ...Option) (VersionResult, error) {
+	i := slices.IndexFunc(config.config.Versions, func(v types.VersionsConfig) bool {
+		return v.Key == systemKey && v.Env == env
+	})
+	if i < 0 {
+		return VersionResult{}, fmt.Errorf("%w: %s (env: %s)", ErrSystemNotFound, systemKey, env)
+	}
+
+	system := config.config.Versions[i]
+	source, ok := newOptions(opts).sources[system.Source]
+	if !ok {
+		return VersionResult{}, fmt.Errorf("%w: %s", ErrNoVersionSource, system.Source)
+	}
+
+	result := source.FetchVersion(ctx, system)
+	return result, result.Err
+}
@@ -0,0 +1,183 @@
+package ecsv_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/dhth/ecsv/pkg/ecsv"
+)
+
+const configStr = `
+env-sequence: ["qa", "staging"]
+envs:
+  qa:
+    aws-config-source: default
+    aws-region: eu-central-1
+    cluster: 1brd-qa
+    service: "{{.Key}}-fargate"
+    container-name: "{{.Key}}"
+  staging:
+    aws-config-source: default
+    aws-region: eu-central-1
+    cluster: 1brd-staging
+    service: "{{.Key}}-fargate"
+    container-name: "{{.Key}}"
+systems:
+  - key: service-a
+    envs: [qa, staging]
+  - key: service-b
+    envs: [qa, staging]
+`
+
+func TestParseConfig(t *testing.T) {
+	config, err := ecsv.ParseConfig([]byte(configStr), ecsv.Filters{
+		KeyExclude: regexp.MustCompile("^service-b$"),
+		EnvFilter:  regexp.MustCompile("^staging$"),
+	})
+	if err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+
+	if got := config.EnvSequence(); !slices.Equal(got, []string{"staging"}) {
+		t.Errorf("got env sequence %v, expected [staging]", got)
+	}
+	if got := config.SystemKeys(); !slices.Equal(got, []string{"service-a"}) {
+		t.Errorf("got system keys %v, expected [service-a]", got)
+	}
+}
+
+func TestParseConfigFailsForInvalidConfig(t *testing.T) {
+	testCases := []struct {
+		name     string
+		config   string
+		expected error
+	}{
+		{
+			name:     "env not in env-sequence",
+			config:   configStr + "  - key: service-c\n    envs: [{name: prod}]\n",
+			expected: ecsv.ErrConfigIsInvalid,
+		},
+		{
+			name:     "invalid concurrency limit",
+			config:   "concurrency:\n  github:\n  - host: github.com\n    max-concurrent-fetches: 0\n" + configStr,
+			expected: ecsv.ErrConfigIsInvalid,
+		},
+		{
+			name:     "include",
+			config:   "include: [teams/*.yml]\n" + configStr,
+			expected: ecsv.ErrIncludeNotSupported,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ecsv.ParseConfig([]byte(tt.config), ecsv.Filters{})
+			if !errors.Is(err, tt.expected) {
+				t.Errorf("got error %v, expected %q", err, tt.expected)
+			}
+			if err != nil && strings.Count(err.Error(), tt.expected.Error()) != 1 {
+				t.Errorf("expected %q to appear once in error, got: %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestCheckStopsWhenContextIsDone(t *testing.T) {
+	t.Setenv("AWS_CONFIG_FILE", os.DevNull)
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", os.DevNull)
+
+	config, err := ecsv.ParseConfig([]byte(configStr), ecsv.Filters{})
+	if err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var handled int
+	report, err := ecsv.Check(ctx, config, ecsv.WithResultHandler(func(ecsv.VersionResult) {
+		handled++
+	}))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("got error %v, expected %q", err, context.Canceled)
+	}
+
+	if handled != 4 {
+		t.Errorf("result handler was called %d times, expected 4", handled)
+	}
+	for _, key := range report.SystemKeys {
+		for _, env := range report.EnvSequence {
+			if r := report.Versions[key][env]; !errors.Is(r.Err, context.Canceled) {
+				t.Errorf("got error %v for %s (%s), expected %q", r.Err, key, env, context.Canceled)
+			}
+		}
+	}
+}
+
+func TestCheckFailsForInvalidOptions(t *testing.T) {
+	config, err := ecsv.ParseConfig([]byte(configStr), ecsv.Filters{})
+	if err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+
+	_, err = ecsv.Check(context.Background(), config, ecsv.WithMaxConcurrentFetches(0))
+	if !errors.Is(err, ecsv.ErrOptionIsInvalid) {
+		t.Errorf("got error %v, expected %q", err, ecsv.ErrOptionIsInvalid)
+	}
+}
+
+type mockSource struct {
+	versions map[string]string
+}
+
+func (m mockSource) FetchVersion(_ context.Context, system ecsv.SystemConfig) ecsv.VersionResult {
+	version, ok := m.versions[system.Key+"/"+system.Env]
+	return ecsv.VersionResult{
+		SystemKey: system.Key,
+		Env:       system.Env,
+		Version:   version,
+		Found:     ok,
+	}
+}
+
+func TestCheckUsesProvidedVersionSource(t *testing.T) {
+	config, err := ecsv.ParseConfig([]byte(configStr), ecsv.Filters{})
+	if err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+
+	source := mockSource{versions: map[string]string{
+		"service-a/qa":      "1.2.0",
+		"service-a/staging": "1.1.0",
+		"service-b/qa":      "2.0.0",
+		"service-b/staging": "2.0.0",
+	}}
+
+	report, err := ecsv.Check(context.Background(), config, ecsv.WithVersionSource(ecsv.ECSSource, source))
+	if err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+
+	if got := report.Versions["service-a"]["staging"].Version; got != "1.1.0" {
+		t.Errorf("got version %q for service-a (staging), expected 1.1.0", got)
+	}
+
+	expected := map[string]ecsv.DriftDirection{
+		"service-a": ecsv.UpstreamAhead,
+		"service-b": ecsv.InSync,
+	}
+	for key, direction := range expected {
+		drifts := report.Drift[key].Drifts
+		if len(drifts) != 1 {
+			t.Fatalf("got %d drifts for %s, expected 1", len(drifts), key)
+		}
+		if drifts[0].Direction != direction {
+			t.Errorf("got drift %q for %s, expected direction %d", drifts[0], key, direction)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
@@ -68,4 +69,204 @@ func TestCLI(t *testing.T) {
 		// THEN
 		assert.NoError(t, err)
 	})
+
+	t.Run("Parsing correct config works for junit output", func(t *testing.T) {
+		// GIVEN
+		c := exec.Command(
+			binPath,
+			"check",
+			"--debug",
+			"-c",
+			"assets/config.yml",
+			"-f",
+			"junit",
+		)
+
+		// WHEN
+		err := c.Run()
+
+		// THEN
+		assert.NoError(t, err)
+	})
+
+	t.Run("Filtering envs and systems works", func(t *testing.T) {
+		// GIVEN
+		c := exec.Command(
+			binPath,
+			"check",
+			"--debug",
+			"-c",
+			"assets/config.yml",
+			"--env-filter",
+			"^qa$",
+			"--key-exclude",
+			"service-b",
+		)
+
+		// WHEN
+		b, err := c.CombinedOutput()
+
+		// THEN
+		require.NoError(t, err, "output:\n%s", b)
+		assert.Contains(t, string(b), "env sequence          [qa]")
+		assert.Contains(t, string(b), "system keys           [service-a]")
+	})
+
+	t.Run("Filtering systems by tags works", func(t *testing.T) {
+		// GIVEN
+		c := exec.Command(
+			binPath,
+			"check",
+			"--debug",
+			"-c",
+			"assets/config.yml",
+			"--tags",
+			"critical",
+		)
+
+		// WHEN
+		b, err := c.CombinedOutput()
+
+		// THEN
+		require.NoError(t, err, "output:\n%s", b)
+		assert.Contains(t, string(b), "system keys           [service-a]")
+	})
+
+	t.Run("Filtering systems by groups works", func(t *testing.T) {
+		// GIVEN
+		c := exec.Command(
+			binPath,
+			"check",
+			"--debug",
+			"-c",
+			"assets/config.yml",
+			"--groups",
+			"checkout",
+		)
+
+		// WHEN
+		b, err := c.CombinedOutput()
+
+		// THEN
+		require.NoError(t, err, "output:\n%s", b)
+		assert.Contains(t, string(b), "system keys           [service-a]")
+	})
+
+	t.Run("Loading config from a directory with includes works", func(t *testing.T) {
+		// GIVEN
+		c := exec.Command(
+			binPath,
+			"check",
+			"--debug",
+			"-c",
+			"assets/config-dir",
+		)
+
+		// WHEN
+		b, err := c.CombinedOutput()
+
+		// THEN
+		require.NoError(t, err, "output:\n%s", b)
+		assert.Contains(t, string(b), "system keys           [service-a service-b]")
+	})
+
+	t.Run("Validating correct config works", func(t *testing.T) {
+		// GIVEN
+		c := exec.Command(
+			binPath,
+			"config",
+			"validate",
+			"-c",
+			"assets/config.yml",
+		)
+
+		// WHEN
+		b, err := c.CombinedOutput()
+
+		// THEN
+		require.NoError(t, err, "output:\n%s", b)
+		assert.Contains(t, string(b), "config is valid")
+	})
+
+	t.Run("Printing config schema works", func(t *testing.T) {
+		// GIVEN
+		c := exec.Command(binPath, "config", "schema", "-c", "assets/nonexistent.yml")
+
+		// WHEN
+		b, err := c.Output()
+
+		// THEN
+		require.NoError(t, err, "output:\n%s", b)
+		assert.True(t, json.Valid(b))
+	})
+
+	// FAILURES
+	t.Run("Validating incorrect config reports all problems", func(t *testing.T) {
+		// GIVEN
+		c := exec.Command(
+			binPath,
+			"config",
+			"validate",
+			"-c",
+			"assets/invalid-config.yml",
+		)
+
+		// WHEN
+		b, err := c.CombinedOutput()
+
+		// THEN
+		require.Error(t, err)
+		assert.Contains(t, string(b), `assets/invalid-config.yml:5:9: [service-a] unknown field "contianer-name"; did you mean "container-name"?`)
+		assert.Contains(t, string(b), `assets/invalid-config.yml:7:10: [service-a] duplicate system key`)
+	})
+
+	t.Run("Conflicting config files fail", func(t *testing.T) {
+		// GIVEN
+		c := exec.Command(
+			binPath,
+			"check",
+			"--debug",
+			"-c",
+			"assets/config-conflict",
+		)
+
+		// WHEN
+		b, err := c.CombinedOutput()
+
+		// THEN
+		require.Error(t, err)
+		assert.Contains(t, string(b), "env-sequence is set differently in more than one file")
+	})
+
+	t.Run("Discovering without a region fails", func(t *testing.T) {
+		// GIVEN
+		c := exec.Command(binPath, "discover", "-p", "qa")
+		c.Env = append(os.Environ(), "AWS_REGION=")
+
+		// WHEN
+		b, err := c.CombinedOutput()
+
+		// THEN
+		require.Error(t, err)
+		assert.Contains(t, string(b), "no region provided for profile")
+	})
+
+	t.Run("Incorrect env filter fails", func(t *testing.T) {
+		// GIVEN
+		c := exec.Command(
+			binPath,
+			"check",
+			"--debug",
+			"-c",
+			"assets/config.yml",
+			"--env-exclude",
+			"qa(",
+		)
+
+		// WHEN
+		err := c.Run()
+
+		// THEN
+		assert.Error(t, err)
+	})
 }